@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    type VARCHAR(32) NOT NULL,
+    quantity INT NOT NULL,
+    balance INT NOT NULL,
+    reference_type VARCHAR(32),
+    reference_id INT,
+    created_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_created_at ON stock_movements (product_id, created_at);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS stock_adjustments (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL,
+    new_stock INT,
+    reason VARCHAR(32) NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    value INT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL,
+    created_by VARCHAR(100),
+    reviewed_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_adjustments_status ON stock_adjustments (status);
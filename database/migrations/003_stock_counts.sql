@@ -0,0 +1,32 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode VARCHAR(64) UNIQUE;
+
+CREATE TABLE IF NOT EXISTS stock_counts (
+    id SERIAL PRIMARY KEY,
+    category_id INT REFERENCES categories(id),
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    notes TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(100),
+    finalized_by VARCHAR(100),
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finalized_at TIMESTAMPTZ
+);
+
+-- system stock snapshot taken when the count starts
+CREATE TABLE IF NOT EXISTS stock_count_items (
+    count_id INT NOT NULL REFERENCES stock_counts(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    system_stock INT NOT NULL,
+    PRIMARY KEY (count_id, product_id)
+);
+
+-- quantities submitted by counters; a product may be counted in several places
+CREATE TABLE IF NOT EXISTS stock_count_entries (
+    id SERIAL PRIMARY KEY,
+    count_id INT NOT NULL REFERENCES stock_counts(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL,
+    counted_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_count_entries_count_product ON stock_count_entries (count_id, product_id);
@@ -0,0 +1,17 @@
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS cost INT NOT NULL DEFAULT 0;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_cost INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS cogs INT NOT NULL DEFAULT 0;
+
+-- inbound stock at its unit cost, consumed oldest first for FIFO costing
+CREATE TABLE IF NOT EXISTS cost_layers (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL,
+    remaining INT NOT NULL,
+    unit_cost INT NOT NULL,
+    stock_movement_id INT REFERENCES stock_movements(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_layers_product_remaining ON cost_layers (product_id, created_at) WHERE remaining > 0;
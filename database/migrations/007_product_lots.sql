@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS product_lots (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    lot_number VARCHAR(64) NOT NULL,
+    expiry_date DATE NOT NULL,
+    quantity INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, lot_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_lots_expiry ON product_lots (expiry_date) WHERE quantity > 0;
+
+-- which lots each sold line was picked from
+CREATE TABLE IF NOT EXISTS transaction_detail_lots (
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id),
+    lot_id INT NOT NULL REFERENCES product_lots(id),
+    quantity INT NOT NULL,
+    PRIMARY KEY (transaction_detail_id, lot_id)
+);
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS stores (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Jakarta',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- existing stock and history belong to the first store
+INSERT INTO stores (id, name) VALUES (1, 'Pusat') ON CONFLICT (id) DO NOTHING;
+SELECT setval('stores_id_seq', GREATEST((SELECT MAX(id) FROM stores), 1));
+
+-- stock per outlet, plus an optional outlet price overriding products.price;
+-- products.stock keeps the total across outlets
+CREATE TABLE IF NOT EXISTS store_products (
+    store_id INT NOT NULL REFERENCES stores(id),
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock INT NOT NULL DEFAULT 0,
+    price INT,
+    PRIMARY KEY (store_id, product_id)
+);
+
+INSERT INTO store_products (store_id, product_id, stock)
+SELECT 1, id, stock FROM products
+ON CONFLICT (store_id, product_id) DO NOTHING;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE stock_adjustments ADD COLUMN IF NOT EXISTS store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE stock_counts ADD COLUMN IF NOT EXISTS store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE purchase_orders ADD COLUMN IF NOT EXISTS store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+
+ALTER TABLE product_lots ADD COLUMN IF NOT EXISTS store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+ALTER TABLE product_lots DROP CONSTRAINT IF EXISTS product_lots_product_id_lot_number_key;
+ALTER TABLE product_lots DROP CONSTRAINT IF EXISTS product_lots_store_product_lot_key;
+ALTER TABLE product_lots ADD CONSTRAINT product_lots_store_product_lot_key UNIQUE (store_id, product_id, lot_number);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_store_created_at ON transactions (store_id, created_at);
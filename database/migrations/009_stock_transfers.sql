@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id SERIAL PRIMARY KEY,
+    from_store_id INT NOT NULL REFERENCES stores(id),
+    to_store_id INT NOT NULL REFERENCES stores(id),
+    status VARCHAR(32) NOT NULL DEFAULT 'draft',
+    notes TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(100),
+    shipped_by VARCHAR(100),
+    received_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    shipped_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ,
+    CHECK (from_store_id <> to_store_id)
+);
+
+-- unit_cost is fixed when the transfer ships; received_quantity stays
+-- NULL until the destination books the delivery
+CREATE TABLE IF NOT EXISTS stock_transfer_items (
+    id SERIAL PRIMARY KEY,
+    transfer_id INT NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL,
+    unit_cost INT NOT NULL DEFAULT 0,
+    received_quantity INT,
+    notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_status ON stock_transfers (status);
@@ -0,0 +1,5 @@
+-- archived products are hidden from the catalogue and checkout but keep
+-- their history
+ALTER TABLE products ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_products_archived_at ON products (archived_at);
@@ -0,0 +1,21 @@
+-- every price a product has had or will have: applied rows are the price
+-- history, scheduled rows wait for the scheduler to apply them
+CREATE TABLE IF NOT EXISTS price_changes (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    old_price INT,
+    new_price INT NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'scheduled',
+    effective_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    applied_at TIMESTAMPTZ
+);
+
+-- the current price of existing products is their first history entry
+INSERT INTO price_changes (product_id, new_price, status, effective_at, applied_at)
+SELECT id, price, 'applied', created_at, created_at FROM products
+WHERE NOT EXISTS (SELECT 1 FROM price_changes);
+
+CREATE INDEX IF NOT EXISTS idx_price_changes_product_id ON price_changes (product_id, effective_at);
+CREATE INDEX IF NOT EXISTS idx_price_changes_due ON price_changes (effective_at) WHERE status = 'scheduled';
@@ -0,0 +1,14 @@
+-- wholesale prices: from min_quantity units of a product in one
+-- transaction, every unit is sold at price
+CREATE TABLE IF NOT EXISTS product_price_tiers (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    min_quantity INT NOT NULL CHECK (min_quantity > 1),
+    price INT NOT NULL CHECK (price > 0),
+    UNIQUE (product_id, min_quantity)
+);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_price INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tier_min_quantity INT;
+
+UPDATE transaction_details SET unit_price = subtotal / quantity WHERE unit_price = 0 AND quantity > 0;
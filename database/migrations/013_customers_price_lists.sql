@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS price_lists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    valid_from TIMESTAMPTZ,
+    valid_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- a rule targets one product or one whole category and sets either a
+-- fixed price or a percentage off the regular price
+CREATE TABLE IF NOT EXISTS price_list_items (
+    id SERIAL PRIMARY KEY,
+    price_list_id INT NOT NULL REFERENCES price_lists(id) ON DELETE CASCADE,
+    product_id INT REFERENCES products(id) ON DELETE CASCADE,
+    category_id INT REFERENCES categories(id) ON DELETE CASCADE,
+    fixed_price INT CHECK (fixed_price > 0),
+    discount_percent NUMERIC(5, 2) CHECK (discount_percent > 0 AND discount_percent <= 100),
+    CHECK ((product_id IS NULL) <> (category_id IS NULL)),
+    CHECK ((fixed_price IS NULL) <> (discount_percent IS NULL))
+);
+
+CREATE TABLE IF NOT EXISTS customer_groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    price_list_id INT REFERENCES price_lists(id)
+);
+
+CREATE TABLE IF NOT EXISTS customers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    group_id INT REFERENCES customer_groups(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id INT REFERENCES customers(id);
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS price_list_id INT REFERENCES price_lists(id);
+
+CREATE INDEX IF NOT EXISTS idx_price_list_items_price_list_id ON price_list_items (price_list_id);
@@ -0,0 +1,4 @@
+-- SKU is the merchant's own product code; imports match products on it
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
@@ -0,0 +1,6 @@
+-- trigram indexes back substring, prefix and typo-tolerant product search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_details_product_id ON transaction_details (product_id);
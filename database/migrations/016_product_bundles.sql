@@ -0,0 +1,24 @@
+-- a bundle is sold as one product but holds no stock of its own; each unit
+-- sold takes quantity units of every component
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_bundle BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS product_bundle_items (
+    bundle_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    component_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, component_id),
+    CHECK (bundle_id <> component_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_bundle_items_component_id ON product_bundle_items (component_id);
+
+-- the component stock a bundle line of a transaction used
+CREATE TABLE IF NOT EXISTS transaction_detail_components (
+    id SERIAL PRIMARY KEY,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    cogs INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_components_detail_id ON transaction_detail_components (transaction_detail_id);
@@ -0,0 +1,4 @@
+-- ingredients are stocked in their own unit (g, ml, pcs) and only leave
+-- stock through the recipes of menu items, which are bundles of them
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_ingredient BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unit VARCHAR(16) NOT NULL DEFAULT 'pcs';
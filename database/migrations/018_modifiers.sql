@@ -0,0 +1,44 @@
+-- a modifier group offers options on a menu item, e.g. size or extras;
+-- max_select 0 leaves a multi-select group unbounded
+CREATE TABLE IF NOT EXISTS modifier_groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    selection VARCHAR(8) NOT NULL DEFAULT 'single' CHECK (selection IN ('single', 'multi')),
+    min_select INT NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select INT NOT NULL DEFAULT 1 CHECK (max_select >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS modifiers (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    price INT NOT NULL DEFAULT 0 CHECK (price >= 0),
+    position INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_modifiers_group_id ON modifiers (group_id);
+
+CREATE TABLE IF NOT EXISTS product_modifier_groups (
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (product_id, group_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_modifier_groups_group_id ON product_modifier_groups (group_id);
+
+-- the modifiers chosen on a transaction line, with their name and price
+-- at the time of sale; modifier_price is their total per unit
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS modifier_price INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_detail_modifiers (
+    id SERIAL PRIMARY KEY,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    modifier_id INT REFERENCES modifiers(id) ON DELETE SET NULL,
+    group_name VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    price INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_modifiers_detail_id ON transaction_detail_modifiers (transaction_detail_id);
@@ -0,0 +1,10 @@
+-- service and non-inventory products are sold without stock; open-price
+-- products take their price from the cashier, within min_price and
+-- max_price when set
+ALTER TABLE products ADD COLUMN IF NOT EXISTS type VARCHAR(16) NOT NULL DEFAULT 'stocked'
+    CHECK (type IN ('stocked', 'service', 'non_inventory'));
+ALTER TABLE products ADD COLUMN IF NOT EXISTS open_price BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS min_price INT CHECK (min_price >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS max_price INT CHECK (max_price >= 0);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS open_price BOOLEAN NOT NULL DEFAULT FALSE;
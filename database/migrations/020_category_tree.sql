@@ -0,0 +1,7 @@
+-- categories nest under a parent, e.g. Minuman > Kopi > Kopi Susu; top
+-- level categories have no parent
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES categories(id);
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_not_self;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
@@ -0,0 +1,11 @@
+-- stock on hand from before costing becomes each product's oldest FIFO
+-- layer, at the product's current cost, so it is consumed first. Opening
+-- layers have no stock movement; the seed runs once per product.
+INSERT INTO cost_layers (product_id, quantity, remaining, unit_cost, created_at)
+SELECT p.id, p.stock - COALESCE(l.remaining, 0), p.stock - COALESCE(l.remaining, 0), p.cost, TIMESTAMPTZ '1970-01-01 00:00:00+00'
+FROM products p
+LEFT JOIN (
+    SELECT product_id, SUM(remaining) AS remaining FROM cost_layers GROUP BY product_id
+) l ON l.product_id = p.id
+WHERE p.stock - COALESCE(l.remaining, 0) > 0
+    AND NOT EXISTS (SELECT 1 FROM cost_layers o WHERE o.product_id = p.id AND o.stock_movement_id IS NULL);
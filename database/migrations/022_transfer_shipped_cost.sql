@@ -0,0 +1,9 @@
+-- the exact cost a transfer line left the source with, so the destination
+-- receives it without rounding loss; lines shipped before keep their
+-- unit cost
+ALTER TABLE stock_transfer_items ADD COLUMN IF NOT EXISTS shipped_cost INT NOT NULL DEFAULT 0;
+
+UPDATE stock_transfer_items i
+SET shipped_cost = i.quantity * i.unit_cost
+FROM stock_transfers t
+WHERE t.id = i.transfer_id AND t.status <> 'draft' AND i.shipped_cost = 0;
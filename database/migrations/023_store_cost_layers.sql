@@ -0,0 +1,35 @@
+-- cost layers belong to the outlet holding the stock and are kept under
+-- both cost methods
+ALTER TABLE cost_layers ADD COLUMN IF NOT EXISTS store_id INT NOT NULL DEFAULT 1 REFERENCES stores(id);
+
+UPDATE cost_layers l
+SET store_id = m.store_id
+FROM stock_movements m
+WHERE l.stock_movement_id = m.id AND l.store_id <> m.store_id;
+
+DROP INDEX IF EXISTS idx_cost_layers_product_remaining;
+CREATE INDEX IF NOT EXISTS idx_cost_layers_store_product_remaining ON cost_layers (store_id, product_id, created_at) WHERE remaining > 0;
+
+-- layers left behind while they were only kept under fifo no longer match
+-- the outlet stock: keep the newest layers up to the stock on hand...
+UPDATE cost_layers l
+SET remaining = GREATEST(LEAST(r.remaining, r.stock - r.newer), 0)
+FROM (
+    SELECT l.id, l.remaining, COALESCE(sp.stock, 0) AS stock,
+        SUM(l.remaining) OVER (PARTITION BY l.store_id, l.product_id ORDER BY l.created_at DESC, l.id DESC) - l.remaining AS newer
+    FROM cost_layers l
+    LEFT JOIN store_products sp ON sp.store_id = l.store_id AND sp.product_id = l.product_id
+    WHERE l.remaining > 0
+) r
+WHERE l.id = r.id AND l.remaining <> GREATEST(LEAST(r.remaining, r.stock - r.newer), 0);
+
+-- ...and seed the stock no layer covers as the oldest layer, at the
+-- product's current cost
+INSERT INTO cost_layers (store_id, product_id, quantity, remaining, unit_cost, created_at)
+SELECT sp.store_id, sp.product_id, sp.stock - COALESCE(l.remaining, 0), sp.stock - COALESCE(l.remaining, 0), p.cost, TIMESTAMPTZ '1970-01-01 00:00:00+00'
+FROM store_products sp
+JOIN products p ON p.id = sp.product_id
+LEFT JOIN (
+    SELECT store_id, product_id, SUM(remaining) AS remaining FROM cost_layers GROUP BY store_id, product_id
+) l ON l.store_id = sp.store_id AND l.product_id = sp.product_id
+WHERE sp.stock - COALESCE(l.remaining, 0) > 0;
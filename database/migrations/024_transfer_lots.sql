@@ -0,0 +1,8 @@
+-- which lots each transfer line was shipped from; the destination
+-- receives the units back into lots with the same number and expiry
+CREATE TABLE IF NOT EXISTS stock_transfer_item_lots (
+    transfer_item_id INT NOT NULL REFERENCES stock_transfer_items(id) ON DELETE CASCADE,
+    lot_id INT NOT NULL REFERENCES product_lots(id),
+    quantity INT NOT NULL,
+    PRIMARY KEY (transfer_item_id, lot_id)
+);
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type BundleHandler struct {
+	service *services.BundleService
+}
+
+func NewBundleHandler(service *services.BundleService) *BundleHandler {
+	return &BundleHandler{service: service}
+}
+
+func (h *BundleHandler) HandleProductComponents(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByProduct(w, r)
+	case http.MethodPut:
+		h.Replace(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/products/{id}/components?store_id=1
+func (h *BundleHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	components, err := h.service.GetComponents(id, storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(components)
+}
+
+// PUT http://localhost:8080/api/products/{id}/components
+func (h *BundleHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	var components []models.BundleComponent
+	err = json.NewDecoder(r.Body).Decode(&components)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateBundleComponents(components); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	components, err = h.service.Replace(id, components)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(components)
+}
+
+func validateBundleComponents(components []models.BundleComponent) error {
+	for _, component := range components {
+		if component.ProductID <= 0 {
+			return errors.New("component product_id is required")
+		}
+
+		if component.Quantity <= 0 {
+			return errors.New("component quantity must be greater than 0")
+		}
+	}
+
+	return nil
+}
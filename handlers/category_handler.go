@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"kasir-go/models"
 	"kasir-go/services"
 	"net/http"
@@ -28,18 +30,80 @@ func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Reques
 	}
 }
 
-// GET http://localhost:8080/api/categories
+// GET http://localhost:8080/api/categories?store_id=1&sort=stock_value&order=desc&limit=50&offset=0
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAll()
+	filter, err := categoryListFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	categories, total, err := h.service.GetAll(filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	json.NewEncoder(w).Encode(categories)
 }
 
+// maxCategoryPage caps the limit of a category list page.
+const maxCategoryPage = 1000
+
+// categoryListFilter reads the query parameters of the category list.
+func categoryListFilter(r *http.Request) (models.CategoryFilter, error) {
+	query := r.URL.Query()
+	var filter models.CategoryFilter
+
+	var err error
+	filter.StoreID, err = storeFilter(r)
+	if err != nil {
+		return filter, err
+	}
+
+	// names sort ascending by default, counts and values largest first
+	filter.Sort = query.Get("sort")
+	switch filter.Sort {
+	case "", models.CategorySortName:
+	case models.CategorySortProductCount, models.CategorySortStockValue:
+		filter.Desc = true
+	default:
+		return filter, errors.New("sort must be name, product_count or stock_value")
+	}
+
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		filter.Desc = false
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, errors.New("order must be asc or desc")
+	}
+
+	if s := query.Get("limit"); s != "" {
+		filter.Limit, err = strconv.Atoi(s)
+		if err != nil || filter.Limit < 1 {
+			return filter, errors.New("invalid limit")
+		}
+
+		if filter.Limit > maxCategoryPage {
+			return filter, errors.New("limit must be at most " + strconv.Itoa(maxCategoryPage))
+		}
+	}
+
+	if s := query.Get("offset"); s != "" {
+		filter.Offset, err = strconv.Atoi(s)
+		if err != nil || filter.Offset < 0 {
+			return filter, errors.New("invalid offset")
+		}
+	}
+
+	return filter, nil
+}
+
 // POST http://localhost:8080/api/categories
 func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var category models.Category
@@ -71,6 +135,58 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
+// GET http://localhost:8080/api/categories/tree
+func (h *CategoryHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tree, err := h.service.GetTree()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+// POST http://localhost:8080/api/categories/{id}/move
+func (h *CategoryHandler) Move(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid category id", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CategoryMoveRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.ParentID != nil && *req.ParentID == id {
+		http.Error(w, "a category cannot be its own parent", http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.service.Move(id, req.ParentID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
 func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -142,7 +258,7 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(category)
 }
 
-// DELETE http://localhost:8080/api/categories/{id}
+// DELETE http://localhost:8080/api/categories/{id}?move_to=5
 func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/categories/")
 
@@ -152,14 +268,33 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	moveTo := 0
+	if s := r.URL.Query().Get("move_to"); s != "" {
+		moveTo, err = strconv.Atoi(s)
+		if err != nil || moveTo < 1 {
+			http.Error(w, "invalid move_to", http.StatusBadRequest)
+			return
+		}
+
+		if moveTo == id {
+			http.Error(w, "move_to must be another category", http.StatusBadRequest)
+			return
+		}
+	}
+
+	moved, err := h.service.Delete(id, moveTo)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	message := "Category deleted"
+	if moveTo != 0 {
+		message = fmt.Sprintf("Category deleted, %d product(s) moved to category id %d", moved, moveTo)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Category deleted",
+		"message": message,
 	})
 }
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type CustomerGroupHandler struct {
+	service *services.CustomerGroupService
+}
+
+func NewCustomerGroupHandler(service *services.CustomerGroupService) *CustomerGroupHandler {
+	return &CustomerGroupHandler{service: service}
+}
+
+func (h *CustomerGroupHandler) HandleCustomerGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/customer-groups
+func (h *CustomerGroupHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// POST http://localhost:8080/api/customer-groups
+func (h *CustomerGroupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var group models.CustomerGroup
+
+	err := json.NewDecoder(r.Body).Decode(&group)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if group.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&group)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+func (h *CustomerGroupHandler) HandleCustomerGroupByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetById(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/customer-groups/{id}
+func (h *CustomerGroupHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid customer group id", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// PUT http://localhost:8080/api/customer-groups/{id}
+func (h *CustomerGroupHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid customer group id", http.StatusBadRequest)
+		return
+	}
+
+	var group models.CustomerGroup
+	err = json.NewDecoder(r.Body).Decode(&group)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if group.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	group.ID = id
+	err = h.service.Update(&group)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// DELETE http://localhost:8080/api/customer-groups/{id}
+func (h *CustomerGroupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid customer group id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Customer group deleted",
+	})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type CustomerHandler struct {
+	service *services.CustomerService
+}
+
+func NewCustomerHandler(service *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+func (h *CustomerHandler) HandleCustomers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/customers?name=budi&group_id=1
+func (h *CustomerHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	groupID := 0
+	if s := r.URL.Query().Get("group_id"); s != "" {
+		var err error
+		groupID, err = strconv.Atoi(s)
+		if err != nil || groupID <= 0 {
+			http.Error(w, "invalid group_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	customers, err := h.service.GetAll(name, groupID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customers)
+}
+
+// POST http://localhost:8080/api/customers
+func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var customer models.Customer
+
+	err := json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if customer.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&customer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(customer)
+}
+
+func (h *CustomerHandler) HandleCustomerByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetById(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/customers/{id}
+func (h *CustomerHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid customer id", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// PUT http://localhost:8080/api/customers/{id}
+func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid customer id", http.StatusBadRequest)
+		return
+	}
+
+	var customer models.Customer
+	err = json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if customer.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	customer.ID = id
+	err = h.service.Update(&customer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// DELETE http://localhost:8080/api/customers/{id}
+func (h *CustomerHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid customer id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Customer deleted",
+	})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type LotHandler struct {
+	service *services.LotService
+}
+
+func NewLotHandler(service *services.LotService) *LotHandler {
+	return &LotHandler{service: service}
+}
+
+func (h *LotHandler) HandleProductLots(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByProduct(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/products/{id}/lots?store_id=1
+func (h *LotHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lots, err := h.service.GetByProductId(id, storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lots)
+}
+
+// POST http://localhost:8080/api/products/{id}/lots
+func (h *LotHandler) Create(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	var lot models.ProductLot
+	err = json.NewDecoder(r.Body).Decode(&lot)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if lot.StoreID == 0 {
+		lot.StoreID, err = requestStoreID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if lot.LotNumber == "" {
+		http.Error(w, "lot number is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := time.Parse("2006-01-02", lot.ExpiryDate); err != nil {
+		http.Error(w, "invalid expiry_date format, use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	if lot.Quantity <= 0 {
+		http.Error(w, "quantity must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	lot.ProductID = id
+	err = h.service.Create(&lot)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(lot)
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type ModifierGroupHandler struct {
+	service *services.ModifierGroupService
+}
+
+func NewModifierGroupHandler(service *services.ModifierGroupService) *ModifierGroupHandler {
+	return &ModifierGroupHandler{service: service}
+}
+
+func (h *ModifierGroupHandler) HandleModifierGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/modifier-groups
+func (h *ModifierGroupHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// POST http://localhost:8080/api/modifier-groups
+func (h *ModifierGroupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var group models.ModifierGroup
+
+	err := json.NewDecoder(r.Body).Decode(&group)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateModifierGroup(&group); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&group)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+func (h *ModifierGroupHandler) HandleModifierGroupByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetById(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/modifier-groups/{id}
+func (h *ModifierGroupHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid modifier group id", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// PUT http://localhost:8080/api/modifier-groups/{id}
+func (h *ModifierGroupHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid modifier group id", http.StatusBadRequest)
+		return
+	}
+
+	var group models.ModifierGroup
+	err = json.NewDecoder(r.Body).Decode(&group)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateModifierGroup(&group); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	group.ID = id
+	err = h.service.Update(&group)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(group)
+}
+
+// DELETE http://localhost:8080/api/modifier-groups/{id}
+func (h *ModifierGroupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid modifier group id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Modifier group deleted",
+	})
+}
+
+func (h *ModifierGroupHandler) HandleProductModifierGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByProduct(w, r)
+	case http.MethodPut:
+		h.ReplaceForProduct(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/products/{id}/modifier-groups
+func (h *ModifierGroupHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.service.GetByProductId(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// PUT http://localhost:8080/api/products/{id}/modifier-groups
+//
+// The body is the list of group ids in display order, e.g. [3, 1].
+func (h *ModifierGroupHandler) ReplaceForProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	var groupIDs []int
+	err = json.NewDecoder(r.Body).Decode(&groupIDs)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.service.ReplaceForProduct(id, groupIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// validateModifierGroup checks a modifier group from a request body and
+// fills in the defaults of a single-select group. It returns the message
+// for a bad request, if any.
+func validateModifierGroup(group *models.ModifierGroup) string {
+	if group.Name == "" {
+		return "name is required"
+	}
+
+	if group.Selection == "" {
+		group.Selection = models.ModifierSelectionSingle
+	}
+
+	if group.MinSelect < 0 || group.MaxSelect < 0 {
+		return "min_select and max_select must not be negative"
+	}
+
+	switch group.Selection {
+	case models.ModifierSelectionSingle:
+		if group.MaxSelect == 0 {
+			group.MaxSelect = 1
+		}
+
+		if group.MinSelect > 1 || group.MaxSelect > 1 {
+			return "a single select group allows at most one choice"
+		}
+	case models.ModifierSelectionMulti:
+		if group.MaxSelect > 0 && group.MaxSelect < group.MinSelect {
+			return "max_select must not be less than min_select"
+		}
+	default:
+		return "selection must be single or multi"
+	}
+
+	if len(group.Modifiers) == 0 {
+		return "modifiers are required"
+	}
+
+	if group.MinSelect > len(group.Modifiers) {
+		return "min_select must not exceed the number of modifiers"
+	}
+
+	for _, modifier := range group.Modifiers {
+		if modifier.Name == "" {
+			return "each modifier needs a name"
+		}
+
+		if modifier.Price < 0 {
+			return "modifier price must not be negative"
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type PriceChangeHandler struct {
+	service *services.PriceChangeService
+}
+
+func NewPriceChangeHandler(service *services.PriceChangeService) *PriceChangeHandler {
+	return &PriceChangeHandler{service: service}
+}
+
+func (h *PriceChangeHandler) HandleProductPrices(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByProduct(w, r)
+	case http.MethodPost:
+		h.Schedule(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/products/{id}/prices
+func (h *PriceChangeHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	prices, err := h.service.GetProductPrices(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prices)
+}
+
+// POST http://localhost:8080/api/products/{id}/prices
+func (h *PriceChangeHandler) Schedule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	// effective_at is an RFC 3339 timestamp, e.g. 2026-03-01T00:00:00+07:00
+	var change models.PriceChange
+	err = json.NewDecoder(r.Body).Decode(&change)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if change.NewPrice <= 0 {
+		http.Error(w, "new_price must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	if change.EffectiveAt.IsZero() {
+		http.Error(w, "effective_at is required", http.StatusBadRequest)
+		return
+	}
+
+	change.ProductID = id
+	change.CreatedBy = requestUser(r)
+	err = h.service.Schedule(&change)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(change)
+}
+
+// DELETE http://localhost:8080/api/products/{id}/prices/{change_id}
+func (h *PriceChangeHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	changeID, err := strconv.Atoi(r.PathValue("change_id"))
+	if err != nil {
+		http.Error(w, "invalid price change id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Cancel(id, changeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Price change cancelled",
+	})
+}
+
+// POST http://localhost:8080/api/products/bulk-price/preview
+func (h *PriceChangeHandler) BulkPreview(w http.ResponseWriter, r *http.Request) {
+	h.bulkUpdate(w, r, false)
+}
+
+// POST http://localhost:8080/api/products/bulk-price
+func (h *PriceChangeHandler) BulkApply(w http.ResponseWriter, r *http.Request) {
+	h.bulkUpdate(w, r, true)
+}
+
+func (h *PriceChangeHandler) bulkUpdate(w http.ResponseWriter, r *http.Request, apply bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// e.g. {"category_id": 3, "percent": 5, "round_to": 500}
+	var req models.BulkPriceRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateBulkPrice(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.BulkUpdate(req, requestUser(r), apply)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// validateBulkPrice checks a bulk price request. It returns the message
+// for a bad request, if any.
+func validateBulkPrice(req *models.BulkPriceRequest) string {
+	if req.CategoryID == 0 && req.Name == "" && len(req.ProductIDs) == 0 {
+		return "choose products by category_id, name or product_ids"
+	}
+
+	if req.CategoryID < 0 {
+		return "invalid category_id"
+	}
+
+	for _, id := range req.ProductIDs {
+		if id <= 0 {
+			return "invalid product id in product_ids"
+		}
+	}
+
+	if (req.Percent == nil) == (req.Amount == nil) {
+		return "set either percent or amount"
+	}
+
+	if req.Percent != nil && (*req.Percent == 0 || *req.Percent <= -100) {
+		return "percent must not be 0 and must be greater than -100"
+	}
+
+	if req.Amount != nil && *req.Amount == 0 {
+		return "amount must not be 0"
+	}
+
+	switch req.RoundTo {
+	case 0, 100, 500, 1000:
+	default:
+		return "round_to must be 100, 500 or 1000"
+	}
+
+	return ""
+}
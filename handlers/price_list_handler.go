@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type PriceListHandler struct {
+	service *services.PriceListService
+}
+
+func NewPriceListHandler(service *services.PriceListService) *PriceListHandler {
+	return &PriceListHandler{service: service}
+}
+
+func (h *PriceListHandler) HandlePriceLists(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/price-lists
+func (h *PriceListHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lists)
+}
+
+// POST http://localhost:8080/api/price-lists
+func (h *PriceListHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var list models.PriceList
+
+	err := json.NewDecoder(r.Body).Decode(&list)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validatePriceList(&list); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&list)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *PriceListHandler) HandlePriceListByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetById(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/price-lists/{id}
+func (h *PriceListHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid price list id", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// PUT http://localhost:8080/api/price-lists/{id}
+func (h *PriceListHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid price list id", http.StatusBadRequest)
+		return
+	}
+
+	var list models.PriceList
+	err = json.NewDecoder(r.Body).Decode(&list)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validatePriceList(&list); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	list.ID = id
+	err = h.service.Update(&list)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// DELETE http://localhost:8080/api/price-lists/{id}
+func (h *PriceListHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid price list id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Price list deleted",
+	})
+}
+
+// validatePriceList checks a price list from a request body. It returns the
+// message for a bad request, if any.
+func validatePriceList(list *models.PriceList) string {
+	if list.Name == "" {
+		return "name is required"
+	}
+
+	if list.ValidFrom != nil && list.ValidUntil != nil && !list.ValidUntil.After(*list.ValidFrom) {
+		return "valid_until must be after valid_from"
+	}
+
+	for _, item := range list.Items {
+		if (item.ProductID == nil) == (item.CategoryID == nil) {
+			return "each item needs either product_id or category_id"
+		}
+
+		if (item.FixedPrice == nil) == (item.DiscountPercent == nil) {
+			return "each item needs either fixed_price or discount_percent"
+		}
+
+		if item.FixedPrice != nil && *item.FixedPrice <= 0 {
+			return "fixed_price must be greater than 0"
+		}
+
+		if item.DiscountPercent != nil && (*item.DiscountPercent <= 0 || *item.DiscountPercent > 100) {
+			return "discount_percent must be greater than 0 and at most 100"
+		}
+	}
+
+	return ""
+}
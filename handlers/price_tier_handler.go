@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type PriceTierHandler struct {
+	service *services.PriceTierService
+}
+
+func NewPriceTierHandler(service *services.PriceTierService) *PriceTierHandler {
+	return &PriceTierHandler{service: service}
+}
+
+func (h *PriceTierHandler) HandleProductPriceTiers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByProduct(w, r)
+	case http.MethodPut:
+		h.Replace(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/products/{id}/price-tiers
+func (h *PriceTierHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	tiers, err := h.service.GetByProductId(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tiers)
+}
+
+// PUT http://localhost:8080/api/products/{id}/price-tiers
+func (h *PriceTierHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	var tiers []models.PriceTier
+	err = json.NewDecoder(r.Body).Decode(&tiers)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	for _, tier := range tiers {
+		if tier.MinQuantity < 2 {
+			http.Error(w, "min_quantity must be at least 2", http.StatusBadRequest)
+			return
+		}
+
+		if tier.Price <= 0 {
+			http.Error(w, "price must be greater than 0", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tiers, err = h.service.Replace(id, tiers)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tiers)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"kasir-go/services"
+	"log"
+	"net/http"
+)
+
+var exportContentTypes = map[string]string{
+	services.FileFormatCSV:  "text/csv; charset=utf-8",
+	services.FileFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	services.FileFormatJSON: "application/json",
+}
+
+type ProductExportHandler struct {
+	service *services.ProductExportService
+}
+
+func NewProductExportHandler(service *services.ProductExportService) *ProductExportHandler {
+	return &ProductExportHandler{service: service}
+}
+
+// GET http://localhost:8080/api/products/export?format=csv&name=&store_id=1&category_id=2
+func (h *ProductExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = services.FileFormatCSV
+	}
+
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		http.Error(w, "format must be csv, xlsx or json", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := productFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="products.`+format+`"`)
+
+	out := &trackingWriter{ResponseWriter: w}
+	err = h.service.Export(out, format, filter)
+	if err != nil {
+		// once rows are on the wire the status can no longer change; the
+		// client sees a truncated file
+		if out.written {
+			log.Printf("product export failed: %v", err)
+			return
+		}
+
+		w.Header().Del("Content-Disposition")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// trackingWriter records whether any of the response body was sent.
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *trackingWriter) Write(p []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(p)
+}
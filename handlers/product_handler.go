@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"kasir-go/models"
 	"kasir-go/services"
 	"net/http"
@@ -28,20 +29,137 @@ func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// GET http://localhost:8080/api/products
+// GET http://localhost:8080/api/products?name=&store_id=1&category_id=2&min_price=1000&max_price=50000&in_stock=true&archived=include&sort=price&order=desc&limit=50&offset=100
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
+	filter, err := productFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	products, err := h.service.GetAll(name)
+	products, total, err := h.service.GetAll(filter)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	json.NewEncoder(w).Encode(products)
 }
 
+// maxProductPage caps the limit of a product list page.
+const maxProductPage = 1000
+
+// productFilter reads the search, filter, sort and page parameters of the
+// product list. Without a limit every match is returned.
+func productFilter(r *http.Request) (models.ProductFilter, error) {
+	query := r.URL.Query()
+	filter := models.ProductFilter{
+		Name:     strings.TrimSpace(query.Get("name")),
+		Archived: models.ProductArchivedExclude,
+	}
+
+	var err error
+	filter.StoreID, err = storeFilter(r)
+	if err != nil {
+		return filter, err
+	}
+
+	number := func(key string, minimum int) (*int, error) {
+		s := query.Get(key)
+		if s == "" {
+			return nil, nil
+		}
+
+		n, err := strconv.Atoi(s)
+		if err != nil || n < minimum {
+			return nil, errors.New("invalid " + key)
+		}
+
+		return &n, nil
+	}
+
+	categoryID, err := number("category_id", 1)
+	if err != nil {
+		return filter, err
+	}
+	if categoryID != nil {
+		filter.CategoryID = *categoryID
+	}
+
+	if filter.MinPrice, err = number("min_price", 0); err != nil {
+		return filter, err
+	}
+
+	if filter.MaxPrice, err = number("max_price", 0); err != nil {
+		return filter, err
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("min_price must not be greater than max_price")
+	}
+
+	if s := query.Get("in_stock"); s != "" {
+		filter.InStock, err = strconv.ParseBool(s)
+		if err != nil {
+			return filter, errors.New("invalid in_stock")
+		}
+	}
+
+	switch s := query.Get("archived"); s {
+	case "", "false", models.ProductArchivedExclude:
+	case "true", models.ProductArchivedOnly:
+		filter.Archived = models.ProductArchivedOnly
+	case models.ProductArchivedInclude:
+		filter.Archived = models.ProductArchivedInclude
+	default:
+		return filter, errors.New("archived must be exclude, only or include")
+	}
+
+	// name, price and stock sort ascending by default, the others newest
+	// or best-selling first
+	filter.Sort = query.Get("sort")
+	switch filter.Sort {
+	case "", models.ProductSortName, models.ProductSortPrice, models.ProductSortStock:
+	case models.ProductSortCreatedAt, models.ProductSortBestSelling:
+		filter.Desc = true
+	default:
+		return filter, errors.New("sort must be name, price, stock, best_selling or created_at")
+	}
+
+	switch query.Get("order") {
+	case "":
+	case "asc":
+		filter.Desc = false
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, errors.New("order must be asc or desc")
+	}
+
+	limit, err := number("limit", 1)
+	if err != nil {
+		return filter, err
+	}
+	if limit != nil {
+		if *limit > maxProductPage {
+			return filter, errors.New("limit must be at most " + strconv.Itoa(maxProductPage))
+		}
+		filter.Limit = *limit
+	}
+
+	offset, err := number("offset", 0)
+	if err != nil {
+		return filter, err
+	}
+	if offset != nil {
+		filter.Offset = *offset
+	}
+
+	return filter, nil
+}
+
 // POST http://localhost:8080/api/products
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var product models.Product
@@ -57,12 +175,42 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if product.Price <= 0 {
-		http.Error(w, "price is required", http.StatusBadRequest)
+	if msg := validateProductPrice(&product); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if len(product.Unit) > 16 {
+		http.Error(w, "unit must be at most 16 characters", http.StatusBadRequest)
+		return
+	}
+
+	if product.Cost < 0 {
+		http.Error(w, "cost must not be negative", http.StatusBadRequest)
 		return
 	}
 
-	if product.Stock <= 0 {
+	if len(product.Components) > 0 {
+		if product.IsIngredient {
+			http.Error(w, "an ingredient cannot have components", http.StatusBadRequest)
+			return
+		}
+
+		if product.Stock != 0 {
+			http.Error(w, "a bundle's stock comes from its components", http.StatusBadRequest)
+			return
+		}
+
+		if err := validateBundleComponents(product.Components); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if product.Type != models.ProductTypeStocked {
+		if product.Stock != 0 {
+			http.Error(w, "service and non-inventory products hold no stock", http.StatusBadRequest)
+			return
+		}
+	} else if product.Stock <= 0 {
 		http.Error(w, "stock are required", http.StatusBadRequest)
 		return
 	}
@@ -72,7 +220,12 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Create(&product)
+	if product.ReorderPoint < 0 || product.ReorderQuantity < 0 {
+		http.Error(w, "reorder point and reorder quantity must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&product, requestUser(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -83,6 +236,69 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(product)
 }
 
+// GET http://localhost:8080/api/products/low-stock
+func (h *ProductHandler) GetLowStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.service.GetLowStock(storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// GET http://localhost:8080/api/products/archived
+func (h *ProductHandler) GetArchived(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	products, err := h.service.GetArchived()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// POST http://localhost:8080/api/products/{id}/restore
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.service.Restore(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
 func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -140,13 +356,13 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if product.Price <= 0 {
-		http.Error(w, "price is required", http.StatusBadRequest)
+	if msg := validateProductPrice(&product); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
 
-	if product.Stock <= 0 {
-		http.Error(w, "stock are required", http.StatusBadRequest)
+	if len(product.Unit) > 16 {
+		http.Error(w, "unit must be at most 16 characters", http.StatusBadRequest)
 		return
 	}
 
@@ -155,8 +371,13 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if product.ReorderPoint < 0 || product.ReorderQuantity < 0 {
+		http.Error(w, "reorder point and reorder quantity must not be negative", http.StatusBadRequest)
+		return
+	}
+
 	product.ID = id
-	err = h.service.Update(&product)
+	err = h.service.Update(&product, requestUser(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -185,6 +406,47 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Product deleted",
+		"message": "Product archived",
 	})
 }
+
+// validateProductPrice checks the type and pricing of a product from a
+// request body, defaulting the type to stocked. It returns the message for
+// a bad request, if any.
+func validateProductPrice(product *models.Product) string {
+	switch product.Type {
+	case "":
+		product.Type = models.ProductTypeStocked
+	case models.ProductTypeStocked, models.ProductTypeService, models.ProductTypeNonInventory:
+	default:
+		return "type must be one of stocked, service, non_inventory"
+	}
+
+	// ingredients are not sold on their own and open-price products are
+	// priced by the cashier, so neither needs a price
+	if product.Price < 0 || product.Price == 0 && !product.IsIngredient && !product.OpenPrice {
+		return "price is required"
+	}
+
+	if !product.OpenPrice {
+		if product.MinPrice != nil || product.MaxPrice != nil {
+			return "min_price and max_price only apply to open-price products"
+		}
+
+		return ""
+	}
+
+	if product.MinPrice != nil && *product.MinPrice < 0 || product.MaxPrice != nil && *product.MaxPrice < 0 {
+		return "min_price and max_price must not be negative"
+	}
+
+	if product.MinPrice != nil && product.MaxPrice != nil && *product.MaxPrice < *product.MinPrice {
+		return "max_price must not be less than min_price"
+	}
+
+	if product.Price > 0 && (product.MinPrice != nil && product.Price < *product.MinPrice || product.MaxPrice != nil && product.Price > *product.MaxPrice) {
+		return "price must be between min_price and max_price"
+	}
+
+	return ""
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/services"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxImportSize caps the size of an uploaded import file.
+const maxImportSize = 32 << 20
+
+type ProductImportHandler struct {
+	service *services.ProductImportService
+}
+
+func NewProductImportHandler(service *services.ProductImportService) *ProductImportHandler {
+	return &ProductImportHandler{service: service}
+}
+
+// POST http://localhost:8080/api/products/import?dry_run=true
+//
+// The body is a multipart form with the CSV or XLSX file in "file". The
+// optional "format" field overrides the format taken from the file name,
+// and "mapping" is a JSON object of product fields to header names, e.g.
+// {"name": "Nama Barang", "price": "Harga"}.
+func (h *ProductImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		http.Error(w, "invalid multipart form", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	format := strings.ToLower(r.FormValue("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+
+	if format != services.FileFormatCSV && format != services.FileFormatXLSX {
+		http.Error(w, "format must be csv or xlsx", http.StatusBadRequest)
+		return
+	}
+
+	var mapping map[string]string
+	if s := r.FormValue("mapping"); s != "" {
+		if err := json.Unmarshal([]byte(s), &mapping); err != nil {
+			http.Error(w, "invalid mapping", http.StatusBadRequest)
+			return
+		}
+	}
+
+	dryRun := false
+	if s := r.FormValue("dry_run"); s != "" {
+		dryRun, err = strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, "invalid dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+
+	storeID, err := requestStoreID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.Import(file, format, mapping, storeID, requestUser(r), dryRun)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if result.Failed > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(result)
+}
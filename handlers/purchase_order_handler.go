@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type PurchaseOrderHandler struct {
+	service *services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+func (h *PurchaseOrderHandler) HandlePurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/purchase-orders?status=sent&supplier_id=1
+func (h *PurchaseOrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var supplierID int
+	if supplierStr := query.Get("supplier_id"); supplierStr != "" {
+		id, err := strconv.Atoi(supplierStr)
+		if err != nil {
+			http.Error(w, "invalid supplier id", http.StatusBadRequest)
+			return
+		}
+		supplierID = id
+	}
+
+	orders, err := h.service.GetAll(query.Get("status"), supplierID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+// POST http://localhost:8080/api/purchase-orders
+func (h *PurchaseOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var po models.PurchaseOrder
+
+	err := json.NewDecoder(r.Body).Decode(&po)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if po.StoreID == 0 {
+		po.StoreID, err = requestStoreID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if po.SupplierID == 0 {
+		http.Error(w, "supplier id is required", http.StatusBadRequest)
+		return
+	}
+
+	if len(po.Items) == 0 {
+		http.Error(w, "items are required", http.StatusBadRequest)
+		return
+	}
+
+	po.CreatedBy = requestUser(r)
+	err = h.service.Create(&po)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(po)
+}
+
+func (h *PurchaseOrderHandler) HandlePurchaseOrderByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetById(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/purchase-orders/{id}
+func (h *PurchaseOrderHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid purchase order id", http.StatusBadRequest)
+		return
+	}
+
+	po, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(po)
+}
+
+// PUT http://localhost:8080/api/purchase-orders/{id}
+func (h *PurchaseOrderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid purchase order id", http.StatusBadRequest)
+		return
+	}
+
+	var po models.PurchaseOrder
+	err = json.NewDecoder(r.Body).Decode(&po)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if po.StoreID == 0 {
+		po.StoreID, err = requestStoreID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if po.SupplierID == 0 {
+		http.Error(w, "supplier id is required", http.StatusBadRequest)
+		return
+	}
+
+	if len(po.Items) == 0 {
+		http.Error(w, "items are required", http.StatusBadRequest)
+		return
+	}
+
+	po.ID = id
+	err = h.service.Update(&po)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(po)
+}
+
+// DELETE http://localhost:8080/api/purchase-orders/{id}
+func (h *PurchaseOrderHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid purchase order id", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Purchase order deleted",
+	})
+}
+
+// POST http://localhost:8080/api/purchase-orders/{id}/send
+func (h *PurchaseOrderHandler) Send(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.service.Send)
+}
+
+// POST http://localhost:8080/api/purchase-orders/{id}/close
+func (h *PurchaseOrderHandler) Close(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.service.Close)
+}
+
+// POST http://localhost:8080/api/purchase-orders/{id}/receive
+func (h *PurchaseOrderHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid purchase order id", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ReceiveRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Items) == 0 {
+		http.Error(w, "items are required", http.StatusBadRequest)
+		return
+	}
+
+	for _, item := range req.Items {
+		if item.LotNumber == "" {
+			continue
+		}
+
+		if _, err := time.Parse("2006-01-02", item.ExpiryDate); err != nil {
+			http.Error(w, "invalid expiry_date format for lot "+item.LotNumber+", use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	po, err := h.service.Receive(id, req.Items, requestUser(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(po)
+}
+
+func (h *PurchaseOrderHandler) transition(w http.ResponseWriter, r *http.Request, action func(int) (*models.PurchaseOrder, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid purchase order id", http.StatusBadRequest)
+		return
+	}
+
+	po, err := action(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(po)
+}
@@ -2,8 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"kasir-go/models"
 	"kasir-go/services"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,14 +20,20 @@ func NewReportHandler(service *services.ReportService) *ReportHandler {
 	return &ReportHandler{service: service}
 }
 
-// GET http://localhost:8080/api/report/today
+// GET http://localhost:8080/api/report/today?store_id=1
 func (h *ReportHandler) GetTodayReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	report, err := h.service.GetTodayReport()
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetTodayReport(storeID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -32,38 +43,361 @@ func (h *ReportHandler) GetTodayReport(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(report)
 }
 
-// GET http://localhost:8080/api/report/today?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD
+// GET http://localhost:8080/api/report/today?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&store_id=1
 func (h *ReportHandler) GetReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetReport(startDate, endDate, storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET http://localhost:8080/api/report/open-purchase-orders?store_id=1
+func (h *ReportHandler) GetOpenPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	orders, err := h.service.GetOpenPurchaseOrders(storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+// GET http://localhost:8080/api/report/supplier-spend?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&store_id=1
+func (h *ReportHandler) GetSupplierSpend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	spend, err := h.service.GetSupplierSpend(startDate, endDate, storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spend)
+}
+
+// GET http://localhost:8080/api/report/component-usage?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&store_id=1
+func (h *ReportHandler) GetComponentUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.service.GetComponentUsage(startDate, endDate, storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+// GET http://localhost:8080/api/report/ingredient-usage?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&store_id=1
+func (h *ReportHandler) GetIngredientUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.service.GetIngredientUsage(startDate, endDate, storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+// GET http://localhost:8080/api/report/profit?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&group_by=product&store_id=1&category_id=2
+func (h *ReportHandler) GetProfitReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case "", "product", "category", "day", "week", "month":
+	default:
+		http.Error(w, "group_by must be one of product, category, day, week, month", http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	categoryID, err := categoryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetProfitReport(startDate, endDate, groupBy, storeID, categoryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// maxSeriesDays caps the range of a sales series per interval so the
+// zero-filled buckets stay within a few hundred.
+var maxSeriesDays = map[string]int{
+	models.SeriesIntervalHour:  31,
+	models.SeriesIntervalDay:   366,
+	models.SeriesIntervalWeek:  5 * 366,
+	models.SeriesIntervalMonth: 10 * 366,
+}
+
+// GET http://localhost:8080/api/report/sales-series?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&interval=day&store_id=1
+func (h *ReportHandler) GetSalesSeries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	switch interval {
+	case "":
+		interval = models.SeriesIntervalDay
+	case models.SeriesIntervalHour, models.SeriesIntervalDay, models.SeriesIntervalWeek, models.SeriesIntervalMonth:
+	default:
+		http.Error(w, "interval must be one of hour, day, week, month", http.StatusBadRequest)
+		return
+	}
+
+	if startDate == nil {
+		http.Error(w, "start_date is required", http.StatusBadRequest)
+		return
+	}
+
+	end := time.Now()
+	if endDate != nil {
+		end = *endDate
+	}
+
+	if end.Before(*startDate) {
+		http.Error(w, "end_date must not be before start_date", http.StatusBadRequest)
+		return
+	}
+
+	if days := int(end.Sub(*startDate).Hours()/24) + 1; days > maxSeriesDays[interval] {
+		http.Error(w, fmt.Sprintf("a %s series covers at most %d days", interval, maxSeriesDays[interval]), http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	series, err := h.service.GetSalesSeries(startDate, endDate, interval, storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// maxRankingLimit caps the number of rows of a sales ranking.
+const maxRankingLimit = 100
+
+// GET http://localhost:8080/api/report/rankings?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&group_by=product&by=revenue&order=top&limit=10&store_id=1&category_id=2
+func (h *ReportHandler) GetSalesRanking(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	query := r.URL.Query()
 
-	var startDatePtr *time.Time
-	if startStr := query.Get("start_date"); startStr != "" {
-		loc, _ := time.LoadLocation("Asia/Jakarta")
-		startDate, err := time.ParseInLocation("2006-01-02", startStr, loc)
-		if err != nil {
-			http.Error(w, "invalid start_date format, use YYYY-MM-DD", http.StatusBadRequest)
+	groupBy := query.Get("group_by")
+	switch groupBy {
+	case "":
+		groupBy = "product"
+	case "product", "category":
+	default:
+		http.Error(w, "group_by must be product or category", http.StatusBadRequest)
+		return
+	}
+
+	by := query.Get("by")
+	switch by {
+	case "":
+		by = models.RankingByQuantity
+	case models.RankingByQuantity, models.RankingByRevenue, models.RankingByProfit:
+	default:
+		http.Error(w, "by must be one of quantity, revenue, profit", http.StatusBadRequest)
+		return
+	}
+
+	var bottom bool
+	switch query.Get("order") {
+	case "", "top":
+	case "bottom":
+		bottom = true
+	default:
+		http.Error(w, "order must be top or bottom", http.StatusBadRequest)
+		return
+	}
+
+	limit := 10
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxRankingLimit {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxRankingLimit), http.StatusBadRequest)
 			return
 		}
-		startDatePtr = &startDate
 	}
 
-	var endDatePtr *time.Time
-	if endStr := query.Get("end_date"); endStr != "" {
-		loc, _ := time.LoadLocation("Asia/Jakarta")
-		endDate, err := time.ParseInLocation("2006-01-02", endStr, loc)
-		if err != nil {
-			http.Error(w, "invalid end_date format, use YYYY-MM-DD", http.StatusBadRequest)
-			return
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	categoryID, err := categoryFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ranking, err := h.service.GetSalesRanking(startDate, endDate, groupBy, by, bottom, limit, storeID, categoryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ranking)
+}
+
+// GET http://localhost:8080/api/report/expiring?horizons=7,30,90&store_id=1
+func (h *ReportHandler) GetExpiringReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var horizons []int
+	if horizonStr := r.URL.Query().Get("horizons"); horizonStr != "" {
+		for _, part := range strings.Split(horizonStr, ",") {
+			days, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || days <= 0 {
+				http.Error(w, "horizons must be a comma separated list of positive day counts", http.StatusBadRequest)
+				return
+			}
+			horizons = append(horizons, days)
 		}
-		endDatePtr = &endDate
 	}
 
-	report, err := h.service.GetReport(startDatePtr, endDatePtr)
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetExpiringReport(horizons, storeID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -72,3 +406,31 @@ func (h *ReportHandler) GetReport(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(report)
 }
+
+// parseDateRange reads the optional start_date and end_date query
+// parameters as Asia/Jakarta calendar dates.
+func parseDateRange(r *http.Request) (startDate, endDate *time.Time, err error) {
+	query := r.URL.Query()
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if startStr := query.Get("start_date"); startStr != "" {
+		sd, err := time.ParseInLocation("2006-01-02", startStr, loc)
+		if err != nil {
+			return nil, nil, errors.New("invalid start_date format, use YYYY-MM-DD")
+		}
+		startDate = &sd
+	}
+
+	if endStr := query.Get("end_date"); endStr != "" {
+		ed, err := time.ParseInLocation("2006-01-02", endStr, loc)
+		if err != nil {
+			return nil, nil, errors.New("invalid end_date format, use YYYY-MM-DD")
+		}
+		endDate = &ed
+	}
+
+	return startDate, endDate, nil
+}
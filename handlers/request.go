@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"kasir-go/models"
+	"net/http"
+	"strconv"
+)
+
+// requestUser returns the operator name sent by the client in the X-User
+// header. It is only used to attribute ledger entries, not for auth.
+func requestUser(r *http.Request) string {
+	return r.Header.Get("X-User")
+}
+
+// requestStoreID returns the outlet of the register, sent in the
+// X-Store-ID header. Requests without one act on the default outlet.
+func requestStoreID(r *http.Request) (int, error) {
+	storeStr := r.Header.Get("X-Store-ID")
+	if storeStr == "" {
+		return models.DefaultStoreID, nil
+	}
+
+	id, err := strconv.Atoi(storeStr)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid X-Store-ID header")
+	}
+
+	return id, nil
+}
+
+// storeFilter reads the optional store_id query parameter of list and
+// report endpoints. Zero means all outlets.
+func storeFilter(r *http.Request) (int, error) {
+	storeStr := r.URL.Query().Get("store_id")
+	if storeStr == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(storeStr)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid store id")
+	}
+
+	return id, nil
+}
+
+// categoryFilter reads the optional category_id query parameter of
+// reports. Zero means every category.
+func categoryFilter(r *http.Request) (int, error) {
+	categoryStr := r.URL.Query().Get("category_id")
+	if categoryStr == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(categoryStr)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid category id")
+	}
+
+	return id, nil
+}
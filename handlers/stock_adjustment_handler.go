@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type StockAdjustmentHandler struct {
+	service *services.StockAdjustmentService
+}
+
+func NewStockAdjustmentHandler(service *services.StockAdjustmentService) *StockAdjustmentHandler {
+	return &StockAdjustmentHandler{service: service}
+}
+
+func (h *StockAdjustmentHandler) HandleStockAdjustments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/stock-adjustments?status=pending
+func (h *StockAdjustmentHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+
+	adjustments, err := h.service.GetAll(status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(adjustments)
+}
+
+// POST http://localhost:8080/api/stock-adjustments
+func (h *StockAdjustmentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.StockAdjustmentRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.StoreID == 0 {
+		req.StoreID, err = requestStoreID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.ProductID == 0 {
+		http.Error(w, "product id is required", http.StatusBadRequest)
+		return
+	}
+
+	if (req.Quantity == nil) == (req.NewStock == nil) {
+		http.Error(w, "either quantity or new_stock is required", http.StatusBadRequest)
+		return
+	}
+
+	if req.Quantity != nil && *req.Quantity == 0 {
+		http.Error(w, "quantity must not be 0", http.StatusBadRequest)
+		return
+	}
+
+	if req.NewStock != nil && *req.NewStock < 0 {
+		http.Error(w, "new_stock must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	switch req.Reason {
+	case models.AdjustmentReasonDamaged, models.AdjustmentReasonExpired, models.AdjustmentReasonLost,
+		models.AdjustmentReasonFound, models.AdjustmentReasonCorrection:
+	default:
+		http.Error(w, "reason must be one of damaged, expired, lost, found, correction", http.StatusBadRequest)
+		return
+	}
+
+	adjustment, err := h.service.Create(req, requestUser(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if adjustment.Status == models.AdjustmentStatusPending {
+		w.WriteHeader(http.StatusAccepted)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(adjustment)
+}
+
+// GET http://localhost:8080/api/stock-adjustments/{id}
+func (h *StockAdjustmentHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock adjustment id", http.StatusBadRequest)
+		return
+	}
+
+	adjustment, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(adjustment)
+}
+
+// POST http://localhost:8080/api/stock-adjustments/{id}/approve
+func (h *StockAdjustmentHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.Approve)
+}
+
+// POST http://localhost:8080/api/stock-adjustments/{id}/reject
+func (h *StockAdjustmentHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, h.service.Reject)
+}
+
+func (h *StockAdjustmentHandler) review(w http.ResponseWriter, r *http.Request, action func(int, string) (*models.StockAdjustment, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock adjustment id", http.StatusBadRequest)
+		return
+	}
+
+	// X-User only names the reviewer; the manager key is what authorizes
+	if !h.service.IsManager(r.Header.Get("X-Manager-Key")) {
+		http.Error(w, "reviewing stock adjustments requires a valid manager key", http.StatusForbidden)
+		return
+	}
+
+	adjustment, err := action(id, requestUser(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(adjustment)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type StockCountHandler struct {
+	service *services.StockCountService
+}
+
+func NewStockCountHandler(service *services.StockCountService) *StockCountHandler {
+	return &StockCountHandler{service: service}
+}
+
+func (h *StockCountHandler) HandleStockCounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Start(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/stock-counts
+func (h *StockCountHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	counts, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(counts)
+}
+
+// POST http://localhost:8080/api/stock-counts
+func (h *StockCountHandler) Start(w http.ResponseWriter, r *http.Request) {
+	var count models.StockCount
+
+	err := json.NewDecoder(r.Body).Decode(&count)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if count.StoreID == 0 {
+		count.StoreID, err = requestStoreID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	count.CreatedBy = requestUser(r)
+	err = h.service.Start(&count)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(count)
+}
+
+// GET http://localhost:8080/api/stock-counts/{id}
+func (h *StockCountHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock count id", http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
+
+// POST http://localhost:8080/api/stock-counts/{id}/entries
+func (h *StockCountHandler) AddEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock count id", http.StatusBadRequest)
+		return
+	}
+
+	var entry models.StockCountEntry
+	err = json.NewDecoder(r.Body).Decode(&entry)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if entry.ProductID == 0 && entry.Barcode == "" {
+		http.Error(w, "product id or barcode is required", http.StatusBadRequest)
+		return
+	}
+
+	if entry.Quantity < 0 {
+		http.Error(w, "quantity must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	entry.CountID = id
+	entry.CountedBy = requestUser(r)
+	err = h.service.AddEntry(&entry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GET http://localhost:8080/api/stock-counts/{id}/variance
+func (h *StockCountHandler) GetVarianceReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock count id", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetVarianceReport(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET http://localhost:8080/api/stock-counts/{id}/ingredient-variance
+func (h *StockCountHandler) GetIngredientVarianceReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock count id", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetIngredientVarianceReport(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// POST http://localhost:8080/api/stock-counts/{id}/finalize
+func (h *StockCountHandler) Finalize(w http.ResponseWriter, r *http.Request) {
+	h.close(w, r, h.service.Finalize)
+}
+
+// POST http://localhost:8080/api/stock-counts/{id}/cancel
+func (h *StockCountHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.close(w, r, h.service.Cancel)
+}
+
+func (h *StockCountHandler) close(w http.ResponseWriter, r *http.Request, action func(int, string) (*models.StockCount, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock count id", http.StatusBadRequest)
+		return
+	}
+
+	count, err := action(id, requestUser(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(count)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type StockMovementHandler struct {
+	service *services.StockMovementService
+}
+
+func NewStockMovementHandler(service *services.StockMovementService) *StockMovementHandler {
+	return &StockMovementHandler{service: service}
+}
+
+// GET http://localhost:8080/api/products/{id}/stock-movements?store_id=1
+func (h *StockMovementHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movements, err := h.service.GetByProductId(id, storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+// GET http://localhost:8080/api/products/{id}/stock?as_of=YYYY-MM-DD&store_id=1
+func (h *StockMovementHandler) GetStockAsOf(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	asOf := time.Now()
+	if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+		loc, err := time.LoadLocation("Asia/Jakarta")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		asOfDate, err := time.ParseInLocation("2006-01-02", asOfStr, loc)
+		if err != nil {
+			http.Error(w, "invalid as_of format, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		// stock at the end of the requested day
+		asOf = asOfDate.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	stock, err := h.service.GetStockAsOf(id, storeID, asOf)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stock)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+)
+
+type StockTransferHandler struct {
+	service *services.StockTransferService
+}
+
+func NewStockTransferHandler(service *services.StockTransferService) *StockTransferHandler {
+	return &StockTransferHandler{service: service}
+}
+
+func (h *StockTransferHandler) HandleStockTransfers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/stock-transfers?status=shipped&store_id=1
+func (h *StockTransferHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transfers, err := h.service.GetAll(r.URL.Query().Get("status"), storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+// POST http://localhost:8080/api/stock-transfers
+func (h *StockTransferHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var transfer models.StockTransfer
+
+	err := json.NewDecoder(r.Body).Decode(&transfer)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateStockTransfer(&transfer); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	transfer.CreatedBy = requestUser(r)
+	err = h.service.Create(&transfer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+func (h *StockTransferHandler) HandleStockTransferByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetById(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/stock-transfers/{id}
+func (h *StockTransferHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock transfer id", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// PUT http://localhost:8080/api/stock-transfers/{id}
+func (h *StockTransferHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock transfer id", http.StatusBadRequest)
+		return
+	}
+
+	var transfer models.StockTransfer
+	err = json.NewDecoder(r.Body).Decode(&transfer)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateStockTransfer(&transfer); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	transfer.ID = id
+	err = h.service.Update(&transfer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// POST http://localhost:8080/api/stock-transfers/{id}/ship
+func (h *StockTransferHandler) Ship(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock transfer id", http.StatusBadRequest)
+		return
+	}
+
+	// an empty body ships without expired lots
+	var req models.TransferShipRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	transfer, err := h.service.Ship(id, req, requestUser(r), r.Header.Get("X-Manager-Key"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// POST http://localhost:8080/api/stock-transfers/{id}/cancel
+func (h *StockTransferHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.service.Cancel)
+}
+
+// POST http://localhost:8080/api/stock-transfers/{id}/receive
+func (h *StockTransferHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock transfer id", http.StatusBadRequest)
+		return
+	}
+
+	// an empty body receives every line in full
+	var req models.TransferReceiveRequest
+	if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	for _, item := range req.Items {
+		if item.Quantity < 0 {
+			http.Error(w, "quantity must not be negative", http.StatusBadRequest)
+			return
+		}
+	}
+
+	transfer, err := h.service.Receive(id, req.Items, requestUser(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// GET http://localhost:8080/api/stock-transfers/in-transit?store_id=1
+func (h *StockTransferHandler) GetInTransit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	storeID, err := storeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.service.GetInTransit(storeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *StockTransferHandler) transition(w http.ResponseWriter, r *http.Request, action func(int, string) (*models.StockTransfer, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid stock transfer id", http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := action(id, requestUser(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// validateStockTransfer checks the required fields of a transfer body and
+// returns the message for a bad request, if any.
+func validateStockTransfer(transfer *models.StockTransfer) string {
+	if transfer.FromStoreID == 0 || transfer.ToStoreID == 0 {
+		return "from_store_id and to_store_id are required"
+	}
+
+	if len(transfer.Items) == 0 {
+		return "items are required"
+	}
+
+	return ""
+}
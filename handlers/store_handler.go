@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-go/models"
+	"kasir-go/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type StoreHandler struct {
+	service *services.StoreService
+}
+
+func NewStoreHandler(service *services.StoreService) *StoreHandler {
+	return &StoreHandler{service: service}
+}
+
+func (h *StoreHandler) HandleStores(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/stores
+func (h *StoreHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	stores, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stores)
+}
+
+// POST http://localhost:8080/api/stores
+func (h *StoreHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var store models.Store
+
+	err := json.NewDecoder(r.Body).Decode(&store)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateStore(&store); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&store)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(store)
+}
+
+func (h *StoreHandler) HandleStoreByID(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetById(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET http://localhost:8080/api/stores/{id}
+func (h *StoreHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid store id", http.StatusBadRequest)
+		return
+	}
+
+	store, err := h.service.GetById(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(store)
+}
+
+// PUT http://localhost:8080/api/stores/{id}
+func (h *StoreHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid store id", http.StatusBadRequest)
+		return
+	}
+
+	var store models.Store
+	err = json.NewDecoder(r.Body).Decode(&store)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateStore(&store); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	store.ID = id
+	err = h.service.Update(&store)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(store)
+}
+
+// GET http://localhost:8080/api/products/{id}/stores
+func (h *StoreHandler) GetProductStores(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	stores, err := h.service.GetProductStores(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stores)
+}
+
+// PUT http://localhost:8080/api/products/{id}/stores/{store_id}
+func (h *StoreHandler) SetProductPrice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	storeID, err := strconv.Atoi(r.PathValue("store_id"))
+	if err != nil {
+		http.Error(w, "invalid store id", http.StatusBadRequest)
+		return
+	}
+
+	var sp models.StoreProduct
+	err = json.NewDecoder(r.Body).Decode(&sp)
+	if err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// a null price removes the override
+	if sp.Price != nil && *sp.Price <= 0 {
+		http.Error(w, "price must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	sp.ProductID = id
+	sp.StoreID = storeID
+	err = h.service.SetProductPrice(&sp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sp)
+}
+
+// validateStore checks a store from a request body and fills in the
+// default timezone. It returns the message for a bad request, if any.
+func validateStore(store *models.Store) string {
+	if store.Name == "" {
+		return "name is required"
+	}
+
+	if store.Timezone == "" {
+		store.Timezone = "Asia/Jakarta"
+	}
+
+	if _, err := time.LoadLocation(store.Timezone); err != nil {
+		return "invalid timezone"
+	}
+
+	return ""
+}
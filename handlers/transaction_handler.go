@@ -34,7 +34,20 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items)
+	if req.StoreID == 0 {
+		req.StoreID, err = requestStoreID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.CustomerID < 0 {
+		http.Error(w, "invalid customer_id", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.Checkout(req, requestUser(r), r.Header.Get("X-Manager-Key"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
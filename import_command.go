@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/services"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runImportProducts imports a product file from the command line and
+// prints the row report. It returns the process exit code.
+func runImportProducts(args []string, service *services.ProductImportService) int {
+	fs := flag.NewFlagSet("import-products", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	storeID := fs.Int("store", models.DefaultStoreID, "outlet receiving the stock")
+	user := fs.String("user", "import", "operator recorded on price history and stock movements")
+	format := fs.String("format", "", "csv or xlsx, taken from the file name when empty")
+	mappingFlag := fs.String("map", "", "field=Header pairs separated by commas, e.g. name=Nama Barang,price=Harga")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: kasir-go import-products [flags] <file>")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	mapping := make(map[string]string)
+	if *mappingFlag != "" {
+		for _, pair := range strings.Split(*mappingFlag, ",") {
+			field, header, ok := strings.Cut(pair, "=")
+			if !ok {
+				fmt.Fprintf(os.Stderr, "invalid mapping %q, use field=Header\n", pair)
+				return 2
+			}
+			mapping[strings.TrimSpace(field)] = strings.TrimSpace(header)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer file.Close()
+
+	result, err := service.Import(file, strings.ToLower(*format), mapping, *storeID, *user, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(result)
+	} else {
+		printImportResult(result)
+	}
+
+	if result.Failed > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func printImportResult(result *models.ProductImportResult) {
+	for _, row := range result.Rows {
+		switch row.Action {
+		case models.ProductImportError:
+			fmt.Printf("row %d\t%s\terror\t%s\n", row.Row, row.SKU, strings.Join(row.Errors, "; "))
+		default:
+			fmt.Printf("row %d\t%s\t%s\tproduct %d\n", row.Row, row.SKU, row.Action, row.ProductID)
+		}
+	}
+
+	if len(result.CategoriesCreated) > 0 {
+		fmt.Printf("new categories: %s\n", strings.Join(result.CategoriesCreated, ", "))
+	}
+
+	status := "applied"
+	switch {
+	case result.Failed > 0:
+		status = "not applied, fix the rows with errors"
+	case result.DryRun:
+		status = "dry run, nothing saved"
+	}
+
+	fmt.Printf("%d rows: %d created, %d updated, %d failed (%s)\n", result.Total, result.Created, result.Updated, result.Failed, status)
+}
@@ -6,12 +6,19 @@ import (
 	"kasir-go/database"
 	"kasir-go/handlers"
 	"kasir-go/middlewares"
+	"kasir-go/models"
+	"kasir-go/notifiers"
 	"kasir-go/repositories"
 	"kasir-go/services"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+	// report periods are in Asia/Jakarta or the outlet's timezone, which
+	// must load even where the host has no zoneinfo
+	_ "time/tzdata"
 
 	"github.com/spf13/viper"
 )
@@ -20,6 +27,18 @@ type Config struct {
 	Port   string `mapstructure:"PORT"`
 	DBConn string `mapstructure:"DB_CONN"`
 	APIKey string `mapstructure:"API_KEY"`
+
+	AdjustmentApprovalThreshold int    `mapstructure:"ADJUSTMENT_APPROVAL_THRESHOLD"`
+	CostMethod                  string `mapstructure:"COST_METHOD"`
+	ManagerKey                  string `mapstructure:"MANAGER_KEY"`
+	ExpiryHorizons              string `mapstructure:"EXPIRY_HORIZONS"`
+	PriceSchedulerInterval      int    `mapstructure:"PRICE_SCHEDULER_INTERVAL"`
+
+	Notifier   string `mapstructure:"NOTIFIER"`
+	WebhookURL string `mapstructure:"WEBHOOK_URL"`
+	SMTPAddr   string `mapstructure:"SMTP_ADDR"`
+	SMTPFrom   string `mapstructure:"SMTP_FROM"`
+	SMTPTo     string `mapstructure:"SMTP_TO"`
 }
 
 func main() {
@@ -35,6 +54,61 @@ func main() {
 		Port:   viper.GetString("PORT"),
 		DBConn: viper.GetString("DB_CONN"),
 		APIKey: viper.GetString("API_KEY"),
+
+		AdjustmentApprovalThreshold: viper.GetInt("ADJUSTMENT_APPROVAL_THRESHOLD"),
+		CostMethod:                  viper.GetString("COST_METHOD"),
+		ManagerKey:                  viper.GetString("MANAGER_KEY"),
+		ExpiryHorizons:              viper.GetString("EXPIRY_HORIZONS"),
+		PriceSchedulerInterval:      viper.GetInt("PRICE_SCHEDULER_INTERVAL"),
+
+		Notifier:   viper.GetString("NOTIFIER"),
+		WebhookURL: viper.GetString("WEBHOOK_URL"),
+		SMTPAddr:   viper.GetString("SMTP_ADDR"),
+		SMTPFrom:   viper.GetString("SMTP_FROM"),
+		SMTPTo:     viper.GetString("SMTP_TO"),
+	}
+
+	switch config.CostMethod {
+	case "":
+		config.CostMethod = models.CostMethodAverage
+	case models.CostMethodAverage, models.CostMethodFIFO:
+	default:
+		log.Fatalf("Invalid COST_METHOD %q, use average or fifo", config.CostMethod)
+	}
+
+	expiryHorizons := []int{7, 30, 90}
+	if config.ExpiryHorizons != "" {
+		expiryHorizons = nil
+		for _, part := range strings.Split(config.ExpiryHorizons, ",") {
+			days, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || days <= 0 {
+				log.Fatalf("Invalid EXPIRY_HORIZONS %q, use comma separated day counts", config.ExpiryHorizons)
+			}
+			expiryHorizons = append(expiryHorizons, days)
+		}
+	}
+
+	// seconds between runs of the scheduled price change job
+	if config.PriceSchedulerInterval <= 0 {
+		config.PriceSchedulerInterval = 60
+	}
+
+	var notifier notifiers.Notifier
+	switch config.Notifier {
+	case "webhook":
+		if config.WebhookURL == "" {
+			log.Fatal("NOTIFIER=webhook needs WEBHOOK_URL")
+		}
+		notifier = notifiers.NewWebhookNotifier(config.WebhookURL)
+	case "email":
+		if config.SMTPAddr == "" || config.SMTPTo == "" {
+			log.Fatal("NOTIFIER=email needs SMTP_ADDR and SMTP_TO")
+		}
+		notifier = notifiers.NewEmailNotifier(config.SMTPAddr, config.SMTPFrom, strings.Split(config.SMTPTo, ","))
+	case "", "log":
+		notifier = notifiers.NewLogNotifier()
+	default:
+		log.Fatalf("Invalid NOTIFIER %q, use log, webhook or email", config.Notifier)
 	}
 
 	// setup database
@@ -47,28 +121,157 @@ func main() {
 	apiKeyMiddleware := middlewares.APIKey(config.APIKey)
 
 	categoryRepo := repositories.NewCategoryRepository(db)
-	productRepo := repositories.NewProductRepository(db)
-	transactionRepo := repositories.NewTransactionRepository(db)
+	productRepo := repositories.NewProductRepository(db, config.CostMethod)
+	transactionRepo := repositories.NewTransactionRepository(db, config.CostMethod)
+	stockMovementRepo := repositories.NewStockMovementRepository(db)
+	stockAdjustmentRepo := repositories.NewStockAdjustmentRepository(db, config.CostMethod)
+	stockCountRepo := repositories.NewStockCountRepository(db, config.CostMethod)
+	supplierRepo := repositories.NewSupplierRepository(db)
+	purchaseOrderRepo := repositories.NewPurchaseOrderRepository(db, config.CostMethod)
+	lotRepo := repositories.NewLotRepository(db)
+	storeRepo := repositories.NewStoreRepository(db)
+	stockTransferRepo := repositories.NewStockTransferRepository(db, config.CostMethod)
+	priceChangeRepo := repositories.NewPriceChangeRepository(db)
+	priceTierRepo := repositories.NewPriceTierRepository(db)
+	priceListRepo := repositories.NewPriceListRepository(db)
+	customerGroupRepo := repositories.NewCustomerGroupRepository(db)
+	customerRepo := repositories.NewCustomerRepository(db)
+	productImportRepo := repositories.NewProductImportRepository(db, config.CostMethod)
+	bundleRepo := repositories.NewBundleRepository(db)
+	modifierGroupRepo := repositories.NewModifierGroupRepository(db)
 
 	categoryService := services.NewCategoryService(categoryRepo, productRepo)
-	productService := services.NewProductService(productRepo, categoryRepo)
-	transactionService := services.NewTransactionService(transactionRepo)
-	reportService := services.NewReportService(transactionRepo)
+	productService := services.NewProductService(productRepo, categoryRepo, bundleRepo, modifierGroupRepo)
+	transactionService := services.NewTransactionService(transactionRepo, notifier, config.ManagerKey)
+	reportService := services.NewReportService(transactionRepo, purchaseOrderRepo, lotRepo, storeRepo, expiryHorizons)
+	stockMovementService := services.NewStockMovementService(stockMovementRepo, productRepo)
+	stockAdjustmentService := services.NewStockAdjustmentService(stockAdjustmentRepo, productRepo, config.AdjustmentApprovalThreshold, config.ManagerKey)
+	stockCountService := services.NewStockCountService(stockCountRepo, productRepo, categoryRepo)
+	supplierService := services.NewSupplierService(supplierRepo)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo, productRepo)
+	lotService := services.NewLotService(lotRepo, productRepo)
+	storeService := services.NewStoreService(storeRepo, productRepo)
+	stockTransferService := services.NewStockTransferService(stockTransferRepo, productRepo, config.ManagerKey)
+	priceChangeService := services.NewPriceChangeService(priceChangeRepo, productRepo)
+	priceTierService := services.NewPriceTierService(priceTierRepo, productRepo)
+	priceListService := services.NewPriceListService(priceListRepo, productRepo, categoryRepo)
+	customerGroupService := services.NewCustomerGroupService(customerGroupRepo, priceListRepo)
+	customerService := services.NewCustomerService(customerRepo, customerGroupRepo)
+	productImportService := services.NewProductImportService(productImportRepo)
+	productExportService := services.NewProductExportService(productRepo)
+	bundleService := services.NewBundleService(bundleRepo, productRepo)
+	modifierGroupService := services.NewModifierGroupService(modifierGroupRepo, productRepo)
+
+	// kasir-go import-products [flags] <file> runs an import and exits
+	// instead of starting the server
+	if len(os.Args) > 1 && os.Args[1] == "import-products" {
+		code := runImportProducts(os.Args[2:], productImportService)
+		db.Close()
+		os.Exit(code)
+	}
 
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 	productHandler := handlers.NewProductHandler(productService)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
 	reportHandler := handlers.NewReportHandler(reportService)
+	stockMovementHandler := handlers.NewStockMovementHandler(stockMovementService)
+	stockAdjustmentHandler := handlers.NewStockAdjustmentHandler(stockAdjustmentService)
+	stockCountHandler := handlers.NewStockCountHandler(stockCountService)
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
+	lotHandler := handlers.NewLotHandler(lotService)
+	storeHandler := handlers.NewStoreHandler(storeService)
+	stockTransferHandler := handlers.NewStockTransferHandler(stockTransferService)
+	priceChangeHandler := handlers.NewPriceChangeHandler(priceChangeService)
+	priceTierHandler := handlers.NewPriceTierHandler(priceTierService)
+	priceListHandler := handlers.NewPriceListHandler(priceListService)
+	customerGroupHandler := handlers.NewCustomerGroupHandler(customerGroupService)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+	productImportHandler := handlers.NewProductImportHandler(productImportService)
+	productExportHandler := handlers.NewProductExportHandler(productExportService)
+	bundleHandler := handlers.NewBundleHandler(bundleService)
+	modifierGroupHandler := handlers.NewModifierGroupHandler(modifierGroupService)
 
+	http.HandleFunc("/api/categories/tree", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(categoryHandler.GetTree))))
+	http.HandleFunc("/api/categories/{id}/move", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(categoryHandler.Move))))
 	http.HandleFunc("/api/categories/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(categoryHandler.HandleCategoryByID))))
 	http.HandleFunc("/api/categories", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(categoryHandler.HandleCategories))))
 
 	http.HandleFunc("/api/products/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(productHandler.HandleProductByID))))
 	http.HandleFunc("/api/products", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(productHandler.HandleProducts))))
+	http.HandleFunc("/api/products/low-stock", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(productHandler.GetLowStock))))
+	http.HandleFunc("/api/products/bulk-price/preview", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(priceChangeHandler.BulkPreview))))
+	http.HandleFunc("/api/products/bulk-price", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(priceChangeHandler.BulkApply))))
+	http.HandleFunc("/api/products/export", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(productExportHandler.Export))))
+	http.HandleFunc("/api/products/import", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(productImportHandler.Import))))
+	http.HandleFunc("/api/products/archived", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(productHandler.GetArchived))))
+	http.HandleFunc("/api/products/{id}/restore", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(productHandler.Restore))))
+	http.HandleFunc("/api/products/{id}/stock-movements", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockMovementHandler.GetByProduct))))
+	http.HandleFunc("/api/products/{id}/lots", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(lotHandler.HandleProductLots))))
+	http.HandleFunc("/api/products/{id}/stock", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockMovementHandler.GetStockAsOf))))
+	http.HandleFunc("/api/products/{id}/prices", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(priceChangeHandler.HandleProductPrices))))
+	http.HandleFunc("/api/products/{id}/prices/{change_id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(priceChangeHandler.Cancel))))
+	http.HandleFunc("/api/products/{id}/price-tiers", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(priceTierHandler.HandleProductPriceTiers))))
+	http.HandleFunc("/api/products/{id}/components", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(bundleHandler.HandleProductComponents))))
+	http.HandleFunc("/api/products/{id}/modifier-groups", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(modifierGroupHandler.HandleProductModifierGroups))))
+	http.HandleFunc("/api/products/{id}/stores", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(storeHandler.GetProductStores))))
+	http.HandleFunc("/api/products/{id}/stores/{store_id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(storeHandler.SetProductPrice))))
+
+	http.HandleFunc("/api/stores/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(storeHandler.HandleStoreByID))))
+	http.HandleFunc("/api/stores", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(storeHandler.HandleStores))))
+
+	http.HandleFunc("/api/customers/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(customerHandler.HandleCustomerByID))))
+	http.HandleFunc("/api/customers", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(customerHandler.HandleCustomers))))
+
+	http.HandleFunc("/api/customer-groups/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(customerGroupHandler.HandleCustomerGroupByID))))
+	http.HandleFunc("/api/customer-groups", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(customerGroupHandler.HandleCustomerGroups))))
+
+	http.HandleFunc("/api/price-lists/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(priceListHandler.HandlePriceListByID))))
+	http.HandleFunc("/api/price-lists", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(priceListHandler.HandlePriceLists))))
+
+	http.HandleFunc("/api/modifier-groups/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(modifierGroupHandler.HandleModifierGroupByID))))
+	http.HandleFunc("/api/modifier-groups", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(modifierGroupHandler.HandleModifierGroups))))
+
+	http.HandleFunc("/api/stock-adjustments/{id}/approve", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockAdjustmentHandler.Approve))))
+	http.HandleFunc("/api/stock-adjustments/{id}/reject", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockAdjustmentHandler.Reject))))
+	http.HandleFunc("/api/stock-adjustments/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockAdjustmentHandler.GetById))))
+	http.HandleFunc("/api/stock-adjustments", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockAdjustmentHandler.HandleStockAdjustments))))
+
+	http.HandleFunc("/api/stock-counts/{id}/entries", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockCountHandler.AddEntry))))
+	http.HandleFunc("/api/stock-counts/{id}/variance", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockCountHandler.GetVarianceReport))))
+	http.HandleFunc("/api/stock-counts/{id}/ingredient-variance", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockCountHandler.GetIngredientVarianceReport))))
+	http.HandleFunc("/api/stock-counts/{id}/finalize", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockCountHandler.Finalize))))
+	http.HandleFunc("/api/stock-counts/{id}/cancel", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockCountHandler.Cancel))))
+	http.HandleFunc("/api/stock-counts/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockCountHandler.GetById))))
+	http.HandleFunc("/api/stock-counts", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockCountHandler.HandleStockCounts))))
+
+	http.HandleFunc("/api/suppliers/", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(supplierHandler.HandleSupplierByID))))
+	http.HandleFunc("/api/suppliers", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(supplierHandler.HandleSuppliers))))
+
+	http.HandleFunc("/api/purchase-orders/{id}/send", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(purchaseOrderHandler.Send))))
+	http.HandleFunc("/api/purchase-orders/{id}/receive", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(purchaseOrderHandler.Receive))))
+	http.HandleFunc("/api/purchase-orders/{id}/close", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(purchaseOrderHandler.Close))))
+	http.HandleFunc("/api/purchase-orders/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(purchaseOrderHandler.HandlePurchaseOrderByID))))
+	http.HandleFunc("/api/purchase-orders", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(purchaseOrderHandler.HandlePurchaseOrders))))
+
+	http.HandleFunc("/api/stock-transfers/in-transit", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockTransferHandler.GetInTransit))))
+	http.HandleFunc("/api/stock-transfers/{id}/ship", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockTransferHandler.Ship))))
+	http.HandleFunc("/api/stock-transfers/{id}/receive", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockTransferHandler.Receive))))
+	http.HandleFunc("/api/stock-transfers/{id}/cancel", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockTransferHandler.Cancel))))
+	http.HandleFunc("/api/stock-transfers/{id}", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockTransferHandler.HandleStockTransferByID))))
+	http.HandleFunc("/api/stock-transfers", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(stockTransferHandler.HandleStockTransfers))))
 
 	http.HandleFunc("/api/checkout", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(transactionHandler.Checkout))))
 
 	http.HandleFunc("/api/report/today", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetTodayReport))))
+	http.HandleFunc("/api/report/open-purchase-orders", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetOpenPurchaseOrders))))
+	http.HandleFunc("/api/report/supplier-spend", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetSupplierSpend))))
+	http.HandleFunc("/api/report/profit", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetProfitReport))))
+	http.HandleFunc("/api/report/component-usage", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetComponentUsage))))
+	http.HandleFunc("/api/report/ingredient-usage", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetIngredientUsage))))
+	http.HandleFunc("/api/report/sales-series", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetSalesSeries))))
+	http.HandleFunc("/api/report/rankings", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetSalesRanking))))
+	http.HandleFunc("/api/report/expiring", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetExpiringReport))))
 	http.HandleFunc("/api/report", middlewares.CORS(middlewares.Logger(apiKeyMiddleware(reportHandler.GetReport))))
 
 	// GET http://localhost:8080/health
@@ -79,6 +282,8 @@ func main() {
 		})
 	})
 
+	go priceChangeService.RunScheduler(time.Duration(config.PriceSchedulerInterval) * time.Second)
+
 	fmt.Println("Server starting on port " + config.Port + "...")
 
 	err = http.ListenAndServe(":"+config.Port, nil)
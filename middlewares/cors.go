@@ -8,7 +8,8 @@ func CORS(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "X-API-Key, Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "X-API-Key, X-User, X-Manager-Key, X-Store-ID, Content-Type")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
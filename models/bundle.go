@@ -0,0 +1,32 @@
+package models
+
+// BundleComponent is a product that makes up a bundle, Quantity units of
+// it per bundle, counted in the component's Unit. Stock is the component's
+// stock where it is read.
+type BundleComponent struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	Unit        string `json:"unit"`
+	Stock       int    `json:"stock"`
+}
+
+// DetailComponent is the component stock used by a bundle line of a
+// transaction.
+type DetailComponent struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	COGS        int    `json:"cogs"`
+}
+
+// ComponentUsage is how much of a product left stock in a period, split
+// into units sold on their own and units used by bundles.
+type ComponentUsage struct {
+	ProductID      int    `json:"product_id"`
+	ProductName    string `json:"product_name"`
+	DirectQuantity int    `json:"direct_quantity"`
+	BundleQuantity int    `json:"bundle_quantity"`
+	TotalQuantity  int    `json:"total_quantity"`
+	BundleCOGS     int    `json:"bundle_cogs"`
+}
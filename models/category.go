@@ -4,4 +4,52 @@ type Category struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+
+	// ParentID is the category this one sits under, nil at the top level.
+	ParentID *int `json:"parent_id"`
+
+	// Children is only filled in when categories are listed as a tree.
+	Children []Category `json:"children,omitempty"`
+}
+
+// CategoryCrumb is one step of the path from the top level down to a
+// category.
+type CategoryCrumb struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// CategoryMoveRequest moves a category, with everything under it, below
+// another category or to the top level when ParentID is nil.
+type CategoryMoveRequest struct {
+	ParentID *int `json:"parent_id"`
+}
+
+// Category list sort keys.
+const (
+	CategorySortName         = "name"
+	CategorySortProductCount = "product_count"
+	CategorySortStockValue   = "stock_value"
+)
+
+// CategoryFilter orders and pages the category list. StoreID values stock
+// at one outlet instead of across all of them. A zero Limit returns every
+// category.
+type CategoryFilter struct {
+	StoreID int
+	Sort    string
+	Desc    bool
+	Limit   int
+	Offset  int
+}
+
+// CategorySummary is a category as listed, with the number of active
+// products in it and its subcategories and the value of their stock at
+// cost. The direct figures leave the subcategories out.
+type CategorySummary struct {
+	Category
+	ProductCount       int `json:"product_count"`
+	StockValue         int `json:"stock_value"`
+	DirectProductCount int `json:"direct_product_count"`
+	DirectStockValue   int `json:"direct_stock_value"`
 }
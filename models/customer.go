@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type Customer struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Phone     string    `json:"phone"`
+	Email     string    `json:"email"`
+	GroupID   *int      `json:"group_id"`
+	GroupName string    `json:"group_name,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CustomerGroup prices its customers' purchases from PriceListID. A group
+// without a price list buys at regular prices.
+type CustomerGroup struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	PriceListID   *int   `json:"price_list_id"`
+	PriceListName string `json:"price_list_name,omitempty"`
+}
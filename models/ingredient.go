@@ -0,0 +1,38 @@
+package models
+
+// IngredientUsage is the theoretical use of an ingredient in a period: what
+// the recipes of the menu items sold call for.
+type IngredientUsage struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Unit        string `json:"unit"`
+	Quantity    int    `json:"quantity"`
+	COGS        int    `json:"cogs"`
+}
+
+// IngredientVariance compares what the recipes used of an ingredient
+// between two stock counts with what actually went: the opening count plus
+// receipts and transfers, less the closing count. Waste booked as an
+// adjustment is part of the actual usage, so a positive Variance is stock
+// used beyond the recipes.
+type IngredientVariance struct {
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	Unit             string `json:"unit"`
+	OpeningCountID   int    `json:"opening_count_id"`
+	OpeningStock     int    `json:"opening_stock"`
+	Received         int    `json:"received"`
+	ClosingStock     int    `json:"closing_stock"`
+	ActualUsage      int    `json:"actual_usage"`
+	TheoreticalUsage int    `json:"theoretical_usage"`
+	Variance         int    `json:"variance"`
+	UnitCost         int    `json:"unit_cost"`
+	ValueImpact      int    `json:"value_impact"`
+}
+
+type IngredientVarianceReport struct {
+	CountID          int                  `json:"count_id"`
+	Status           string               `json:"status"`
+	TotalValueImpact int                  `json:"total_value_impact"`
+	Items            []IngredientVariance `json:"items"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ProductLot is a batch of a product's stock at one outlet sharing one
+// expiry date. Stock not assigned to any lot is treated as untracked.
+type ProductLot struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name,omitempty"`
+	StoreID     int       `json:"store_id"`
+	LotNumber   string    `json:"lot_number"`
+	ExpiryDate  string    `json:"expiry_date"`
+	DaysLeft    int       `json:"days_left"`
+	Quantity    int       `json:"quantity"`
+	UnitCost    int       `json:"unit_cost"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type LotUsage struct {
+	LotID      int    `json:"lot_id"`
+	LotNumber  string `json:"lot_number"`
+	ExpiryDate string `json:"expiry_date"`
+	Quantity   int    `json:"quantity"`
+}
+
+// ExpiryBucket groups lots expiring within WithinDays days. A WithinDays
+// of 0 holds lots that have already expired.
+type ExpiryBucket struct {
+	WithinDays int          `json:"within_days"`
+	Quantity   int          `json:"quantity"`
+	CostValue  int          `json:"cost_value"`
+	Lots       []ProductLot `json:"lots"`
+}
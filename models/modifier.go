@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Selection modes of a modifier group.
+const (
+	ModifierSelectionSingle = "single"
+	ModifierSelectionMulti  = "multi"
+)
+
+// ModifierGroup is a set of options offered on the products it is attached
+// to. A checkout line must pick between MinSelect and MaxSelect of its
+// modifiers; a zero MaxSelect leaves a multi-select group unbounded.
+type ModifierGroup struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Selection string     `json:"selection"`
+	MinSelect int        `json:"min_select"`
+	MaxSelect int        `json:"max_select"`
+	CreatedAt time.Time  `json:"created_at"`
+	Modifiers []Modifier `json:"modifiers"`
+}
+
+// Modifier is one option of a group. Price is added to the unit price of
+// the line that picks it.
+type Modifier struct {
+	ID      int    `json:"id"`
+	GroupID int    `json:"group_id"`
+	Name    string `json:"name"`
+	Price   int    `json:"price"`
+}
+
+// DetailModifier is a modifier picked on a transaction line, as it was
+// named and priced at the time of sale.
+type DetailModifier struct {
+	ModifierID int    `json:"modifier_id"`
+	GroupName  string `json:"group_name"`
+	Name       string `json:"name"`
+	Price      int    `json:"price"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+const (
+	PriceChangeStatusScheduled = "scheduled"
+	PriceChangeStatusApplied   = "applied"
+	PriceChangeStatusCancelled = "cancelled"
+)
+
+// PriceChange is one price a product had or will have. OldPrice is filled
+// in when the change is applied and is nil for a product's first price.
+type PriceChange struct {
+	ID          int        `json:"id"`
+	ProductID   int        `json:"product_id"`
+	OldPrice    *int       `json:"old_price"`
+	NewPrice    int        `json:"new_price"`
+	Status      string     `json:"status"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+}
+
+type ProductPrices struct {
+	ProductID    int           `json:"product_id"`
+	CurrentPrice int           `json:"current_price"`
+	Upcoming     []PriceChange `json:"upcoming"`
+	History      []PriceChange `json:"history"`
+}
+
+// BulkPriceRequest changes the price of every product matching all the
+// given selectors, by Percent or by Amount, either of which may be
+// negative. With RoundTo set the new price is rounded to the nearest
+// multiple of it.
+type BulkPriceRequest struct {
+	CategoryID int      `json:"category_id"`
+	Name       string   `json:"name"`
+	ProductIDs []int    `json:"product_ids"`
+	Percent    *float64 `json:"percent"`
+	Amount     *int     `json:"amount"`
+	RoundTo    int      `json:"round_to"`
+}
+
+type BulkPriceChange struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	OldPrice  int    `json:"old_price"`
+	NewPrice  int    `json:"new_price"`
+}
+
+// BulkPriceResult lists the new price of each selected product. Applied is
+// false for a preview.
+type BulkPriceResult struct {
+	Applied bool              `json:"applied"`
+	Count   int               `json:"count"`
+	Changed int               `json:"changed"`
+	Changes []BulkPriceChange `json:"changes"`
+}
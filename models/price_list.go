@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// PriceList is a set of special prices, valid between ValidFrom and
+// ValidUntil when those are set.
+type PriceList struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	ValidFrom  *time.Time      `json:"valid_from"`
+	ValidUntil *time.Time      `json:"valid_until"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Items      []PriceListItem `json:"items,omitempty"`
+}
+
+// PriceListItem targets either a product or a category and sets either a
+// fixed price or a percentage off the regular price. A product rule wins
+// over the rule of its category.
+type PriceListItem struct {
+	ID              int      `json:"id"`
+	PriceListID     int      `json:"price_list_id"`
+	ProductID       *int     `json:"product_id,omitempty"`
+	CategoryID      *int     `json:"category_id,omitempty"`
+	FixedPrice      *int     `json:"fixed_price,omitempty"`
+	DiscountPercent *float64 `json:"discount_percent,omitempty"`
+}
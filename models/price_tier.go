@@ -0,0 +1,10 @@
+package models
+
+// PriceTier is a wholesale price: when a transaction has at least
+// MinQuantity units of the product, every unit sells at Price.
+type PriceTier struct {
+	ID          int `json:"id"`
+	ProductID   int `json:"product_id"`
+	MinQuantity int `json:"min_quantity"`
+	Price       int `json:"price"`
+}
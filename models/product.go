@@ -1,10 +1,109 @@
 package models
 
+import "time"
+
+// Cost methods used to value the cost of goods sold.
+const (
+	CostMethodAverage = "average"
+	CostMethodFIFO    = "fifo"
+)
+
 type Product struct {
 	ID           int    `json:"id"`
 	Name         string `json:"name"`
 	Price        int    `json:"price"`
+	Cost         int    `json:"cost"`
 	Stock        int    `json:"stock"`
 	CategoryID   int    `json:"category_id"`
 	CategoryName string `json:"category_name,omitempty"`
+
+	// CategoryPath is the breadcrumb of the product's category, from the
+	// top level down.
+	CategoryPath []CategoryCrumb `json:"category_path,omitempty"`
+
+	Barcode string `json:"barcode,omitempty"`
+	SKU     string `json:"sku,omitempty"`
+
+	// ReorderPoint is the stock level at or below which the product is
+	// considered low on stock. Zero disables the alert.
+	ReorderPoint    int `json:"reorder_point"`
+	ReorderQuantity int `json:"reorder_quantity"`
+
+	// ArchivedAt is set while the product is archived: hidden from the
+	// catalogue and checkout, but kept for history and reports.
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	// IsBundle marks a product made of Components. Its stock is the number
+	// of complete bundles the components' stock makes. A menu item whose
+	// components are ingredients is a bundle too: its recipe.
+	IsBundle   bool              `json:"is_bundle"`
+	Components []BundleComponent `json:"components,omitempty"`
+
+	// IsIngredient marks stock that is used by recipes and never sold on
+	// its own. Unit is what stock and recipe quantities are counted in.
+	IsIngredient bool   `json:"is_ingredient"`
+	Unit         string `json:"unit"`
+
+	// ModifierGroups are the options a checkout line of the product can
+	// pick from.
+	ModifierGroups []ModifierGroup `json:"modifier_groups,omitempty"`
+
+	// Type tells whether the product holds stock. Services and
+	// non-inventory products are sold without stock checks or movements.
+	Type string `json:"type"`
+
+	// OpenPrice lets the cashier enter the price at checkout, between
+	// MinPrice and MaxPrice when they are set. Price is the suggestion.
+	OpenPrice bool `json:"open_price"`
+	MinPrice  *int `json:"min_price,omitempty"`
+	MaxPrice  *int `json:"max_price,omitempty"`
+}
+
+// Product types.
+const (
+	ProductTypeStocked      = "stocked"
+	ProductTypeService      = "service"
+	ProductTypeNonInventory = "non_inventory"
+)
+
+// DefaultUnit is the unit of products that do not set one.
+const DefaultUnit = "pcs"
+
+// Product list sort keys. Best-selling ranks by quantity sold over the
+// last 30 days.
+const (
+	ProductSortCreatedAt   = "created_at"
+	ProductSortName        = "name"
+	ProductSortPrice       = "price"
+	ProductSortStock       = "stock"
+	ProductSortBestSelling = "best_selling"
+)
+
+// Archived states a product list can be filtered on.
+const (
+	ProductArchivedExclude = "exclude"
+	ProductArchivedOnly    = "only"
+	ProductArchivedInclude = "include"
+)
+
+// ProductFilter selects and orders a page of the product list. Zero values
+// leave a filter off; a zero Limit returns every match.
+type ProductFilter struct {
+	// Name matches the product name by substring or, to forgive typos, by
+	// trigram similarity, and also matches an exact SKU or barcode.
+	Name    string
+	StoreID int
+
+	// CategoryID matches products in the category or any category below
+	// it.
+	CategoryID int
+	MinPrice   *int
+	MaxPrice   *int
+	InStock    bool
+	Archived   string
+
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
 }
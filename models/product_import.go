@@ -0,0 +1,47 @@
+package models
+
+// ProductImportRecord is one data row of an import file. Nil fields were
+// left empty and keep the product's current value on update.
+type ProductImportRecord struct {
+	Row             int
+	SKU             string
+	Name            string
+	Category        string
+	Barcode         *string
+	Price           *int
+	Cost            *int
+	Stock           *int
+	ReorderPoint    *int
+	ReorderQuantity *int
+}
+
+// Import row actions.
+const (
+	ProductImportCreate = "create"
+	ProductImportUpdate = "update"
+	ProductImportError  = "error"
+)
+
+type ProductImportRow struct {
+	Row       int      `json:"row"`
+	SKU       string   `json:"sku"`
+	Name      string   `json:"name"`
+	Action    string   `json:"action"`
+	ProductID int      `json:"product_id,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+	// Warnings name values of the row that were not imported.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ProductImportResult reports what the import did, or would do on a dry
+// run. Nothing is applied when any row has errors.
+type ProductImportResult struct {
+	DryRun            bool               `json:"dry_run"`
+	Applied           bool               `json:"applied"`
+	Total             int                `json:"total"`
+	Created           int                `json:"created"`
+	Updated           int                `json:"updated"`
+	Failed            int                `json:"failed"`
+	CategoriesCreated []string           `json:"categories_created"`
+	Rows              []ProductImportRow `json:"rows"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusClosed            = "closed"
+)
+
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	SupplierID   int                 `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name,omitempty"`
+	StoreID      int                 `json:"store_id"`
+	Status       string              `json:"status"`
+	Notes        string              `json:"notes"`
+	TotalCost    int                 `json:"total_cost"`
+	CreatedBy    string              `json:"created_by,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	SentAt       *time.Time          `json:"sent_at,omitempty"`
+	ClosedAt     *time.Time          `json:"closed_at,omitempty"`
+	Items        []PurchaseOrderItem `json:"items,omitempty"`
+}
+
+type PurchaseOrderItem struct {
+	ID               int    `json:"id"`
+	PurchaseOrderID  int    `json:"purchase_order_id"`
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name,omitempty"`
+	Quantity         int    `json:"quantity"`
+	UnitCost         int    `json:"unit_cost"`
+	ReceivedQuantity int    `json:"received_quantity"`
+}
+
+type ReceiveRequest struct {
+	Items []ReceiveItem `json:"items"`
+}
+
+type ReceiveItem struct {
+	ItemID   int `json:"item_id"`
+	Quantity int `json:"quantity"`
+
+	// LotNumber and ExpiryDate (YYYY-MM-DD) put the received units in a lot.
+	LotNumber  string `json:"lot_number,omitempty"`
+	ExpiryDate string `json:"expiry_date,omitempty"`
+}
+
+type OpenPurchaseOrder struct {
+	ID                  int       `json:"id"`
+	SupplierID          int       `json:"supplier_id"`
+	SupplierName        string    `json:"supplier_name"`
+	StoreID             int       `json:"store_id"`
+	Status              string    `json:"status"`
+	CreatedAt           time.Time `json:"created_at"`
+	OutstandingQuantity int       `json:"outstanding_quantity"`
+	OutstandingCost     int       `json:"outstanding_cost"`
+}
+
+type SupplierSpend struct {
+	SupplierID    int    `json:"supplier_id"`
+	SupplierName  string `json:"supplier_name"`
+	PurchaseCount int    `json:"purchase_count"`
+	TotalQuantity int    `json:"total_quantity"`
+	TotalSpend    int    `json:"total_spend"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type BestSellingProduct struct {
 	Name         string `json:"name"`
 	QuantitySold int    `json:"quantity_sold"`
@@ -10,3 +12,85 @@ type TodayReport struct {
 	TotalTransaction   int                 `json:"total_transaction"`
 	BestSellingProduct *BestSellingProduct `json:"best_selling_product"`
 }
+
+type ProfitReport struct {
+	GroupBy      string      `json:"group_by,omitempty"`
+	TotalRevenue int         `json:"total_revenue"`
+	TotalCOGS    int         `json:"total_cogs"`
+	GrossProfit  int         `json:"gross_profit"`
+	Margin       float64     `json:"margin"`
+	Rows         []ProfitRow `json:"rows,omitempty"`
+}
+
+// ProfitRow is one product, category or period of a profit report. Key is
+// the period start (YYYY-MM-DD) when grouped by period.
+type ProfitRow struct {
+	Key         string  `json:"key"`
+	ID          int     `json:"id,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	Revenue     int     `json:"revenue"`
+	COGS        int     `json:"cogs"`
+	GrossProfit int     `json:"gross_profit"`
+	Margin      float64 `json:"margin"`
+}
+
+// Sales series intervals.
+const (
+	SeriesIntervalHour  = "hour"
+	SeriesIntervalDay   = "day"
+	SeriesIntervalWeek  = "week"
+	SeriesIntervalMonth = "month"
+)
+
+// SalesSeries is the sales of a period split into hour, day, week or month
+// buckets in Timezone. Buckets without sales are included with zeros.
+type SalesSeries struct {
+	Interval string        `json:"interval"`
+	Timezone string        `json:"timezone"`
+	Buckets  []SalesBucket `json:"buckets"`
+}
+
+// SalesBucket is one bucket of a sales series. Start is the bucket's first
+// moment; weeks start on Monday. AverageBasket is revenue per transaction.
+type SalesBucket struct {
+	Start            time.Time `json:"start"`
+	Revenue          int       `json:"revenue"`
+	TransactionCount int       `json:"transaction_count"`
+	ItemsSold        int       `json:"items_sold"`
+	AverageBasket    int       `json:"average_basket"`
+}
+
+// Sales ranking metrics.
+const (
+	RankingByQuantity = "quantity"
+	RankingByRevenue  = "revenue"
+	RankingByProfit   = "profit"
+)
+
+// SalesRanking is the top or bottom products or categories of a period by
+// one metric. Total is the metric summed over everything ranked, not only
+// the rows returned. CategoryID is the category the ranking was narrowed
+// to, if any.
+type SalesRanking struct {
+	GroupBy    string       `json:"group_by"`
+	By         string       `json:"by"`
+	Bottom     bool         `json:"bottom"`
+	CategoryID int          `json:"category_id,omitempty"`
+	Total      int          `json:"total"`
+	Rows       []RankingRow `json:"rows"`
+}
+
+// RankingRow is one product or category of a sales ranking. Share is its
+// metric as a percentage of the ranking's total. Category fields are only
+// set for products.
+type RankingRow struct {
+	Rank         int     `json:"rank"`
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	CategoryID   int     `json:"category_id,omitempty"`
+	CategoryName string  `json:"category_name,omitempty"`
+	Quantity     int     `json:"quantity"`
+	Revenue      int     `json:"revenue"`
+	Profit       int     `json:"profit"`
+	Share        float64 `json:"share"`
+}
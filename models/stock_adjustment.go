@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	AdjustmentReasonDamaged    = "damaged"
+	AdjustmentReasonExpired    = "expired"
+	AdjustmentReasonLost       = "lost"
+	AdjustmentReasonFound      = "found"
+	AdjustmentReasonCorrection = "correction"
+)
+
+const (
+	AdjustmentStatusPending  = "pending"
+	AdjustmentStatusApplied  = "applied"
+	AdjustmentStatusRejected = "rejected"
+)
+
+type StockAdjustment struct {
+	ID         int        `json:"id"`
+	ProductID  int        `json:"product_id"`
+	StoreID    int        `json:"store_id"`
+	Quantity   int        `json:"quantity"`
+	NewStock   *int       `json:"new_stock,omitempty"`
+	Reason     string     `json:"reason"`
+	Notes      string     `json:"notes"`
+	Value      int        `json:"value"`
+	Status     string     `json:"status"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// StockAdjustmentRequest takes either a quantity delta or an absolute
+// new stock count, never both.
+type StockAdjustmentRequest struct {
+	ProductID int    `json:"product_id"`
+	StoreID   int    `json:"store_id"`
+	Quantity  *int   `json:"quantity"`
+	NewStock  *int   `json:"new_stock"`
+	Reason    string `json:"reason"`
+	Notes     string `json:"notes"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	StockCountStatusOpen      = "open"
+	StockCountStatusFinalized = "finalized"
+	StockCountStatusCancelled = "cancelled"
+)
+
+type StockCount struct {
+	ID          int        `json:"id"`
+	StoreID     int        `json:"store_id"`
+	CategoryID  *int       `json:"category_id,omitempty"`
+	Status      string     `json:"status"`
+	Notes       string     `json:"notes"`
+	ItemCount   int        `json:"item_count"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	FinalizedBy string     `json:"finalized_by,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinalizedAt *time.Time `json:"finalized_at,omitempty"`
+}
+
+type StockCountEntry struct {
+	ID        int       `json:"id"`
+	CountID   int       `json:"count_id"`
+	ProductID int       `json:"product_id"`
+	Barcode   string    `json:"barcode,omitempty"`
+	Quantity  int       `json:"quantity"`
+	CountedBy string    `json:"counted_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StockCountVariance compares the counted quantity with the stock the
+// system expected at the time of counting: the snapshot plus every
+// movement (e.g. sales) posted between the start of the count and the
+// last entry for the product.
+type StockCountVariance struct {
+	ProductID     int    `json:"product_id"`
+	ProductName   string `json:"product_name"`
+	SystemStock   int    `json:"system_stock"`
+	ExpectedStock int    `json:"expected_stock"`
+	CountedStock  *int   `json:"counted_stock"`
+	Variance      int    `json:"variance"`
+	UnitCost      int    `json:"unit_cost"`
+	ValueImpact   int    `json:"value_impact"`
+}
+
+type StockCountVarianceReport struct {
+	CountID          int                  `json:"count_id"`
+	Status           string               `json:"status"`
+	TotalVariance    int                  `json:"total_variance"`
+	TotalValueImpact int                  `json:"total_value_impact"`
+	UncountedItems   int                  `json:"uncounted_items"`
+	Items            []StockCountVariance `json:"items"`
+}
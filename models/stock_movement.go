@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	StockMovementSale            = "sale"
+	StockMovementAdjustment      = "adjustment"
+	StockMovementPurchaseReceipt = "purchase_receipt"
+	StockMovementTransfer        = "transfer"
+)
+
+type StockMovement struct {
+	ID            int       `json:"id"`
+	ProductID     int       `json:"product_id"`
+	StoreID       int       `json:"store_id"`
+	Type          string    `json:"type"`
+	Quantity      int       `json:"quantity"`
+	Balance       int       `json:"balance"`
+	Cost          int       `json:"cost"`
+	ReferenceType string    `json:"reference_type,omitempty"`
+	ReferenceID   int       `json:"reference_id,omitempty"`
+	CreatedBy     string    `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// InboundCost is the total cost of the units of an inbound purchase
+	// receipt, or their shipped cost for an inbound transfer. Other inbound
+	// movements are valued at the product's current cost.
+	InboundCost int `json:"-"`
+}
+
+type StockAsOf struct {
+	ProductID int       `json:"product_id"`
+	StoreID   int       `json:"store_id,omitempty"`
+	Stock     int       `json:"stock"`
+	AsOf      time.Time `json:"as_of"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+const (
+	StockTransferStatusDraft     = "draft"
+	StockTransferStatusShipped   = "shipped"
+	StockTransferStatusReceived  = "received"
+	StockTransferStatusCancelled = "cancelled"
+)
+
+type StockTransfer struct {
+	ID          int                 `json:"id"`
+	FromStoreID int                 `json:"from_store_id"`
+	ToStoreID   int                 `json:"to_store_id"`
+	Status      string              `json:"status"`
+	Notes       string              `json:"notes"`
+	CreatedBy   string              `json:"created_by,omitempty"`
+	ShippedBy   string              `json:"shipped_by,omitempty"`
+	ReceivedBy  string              `json:"received_by,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	ShippedAt   *time.Time          `json:"shipped_at,omitempty"`
+	ReceivedAt  *time.Time          `json:"received_at,omitempty"`
+	Items       []StockTransferItem `json:"items,omitempty"`
+}
+
+// StockTransferItem is one product on a transfer. Discrepancy is the
+// received quantity minus the shipped quantity, negative for shortages.
+type StockTransferItem struct {
+	ID               int    `json:"id"`
+	TransferID       int    `json:"transfer_id"`
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name,omitempty"`
+	Quantity         int    `json:"quantity"`
+	UnitCost         int    `json:"unit_cost"`
+	ShippedCost      int    `json:"shipped_cost"`
+	ReceivedQuantity *int   `json:"received_quantity,omitempty"`
+	Discrepancy      int    `json:"discrepancy"`
+	Notes            string `json:"notes"`
+
+	// Lots are the source lots the line was shipped from.
+	Lots []LotUsage `json:"lots,omitempty"`
+}
+
+// TransferShipRequest ships a draft transfer. AllowExpired lets expired
+// lots be shipped; it needs a valid manager key.
+type TransferShipRequest struct {
+	AllowExpired bool `json:"allow_expired"`
+}
+
+// TransferReceiveRequest books the delivery at the destination. Lines
+// that are not listed are taken as received in full.
+type TransferReceiveRequest struct {
+	Items []TransferReceiveItem `json:"items"`
+}
+
+type TransferReceiveItem struct {
+	ItemID   int    `json:"item_id"`
+	Quantity int    `json:"quantity"`
+	Notes    string `json:"notes"`
+}
+
+// InTransitItem is stock that has left its source outlet on a shipped
+// transfer but has not been received yet.
+type InTransitItem struct {
+	TransferID  int       `json:"transfer_id"`
+	FromStoreID int       `json:"from_store_id"`
+	ToStoreID   int       `json:"to_store_id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Quantity    int       `json:"quantity"`
+	CostValue   int       `json:"cost_value"`
+	ShippedAt   time.Time `json:"shipped_at"`
+}
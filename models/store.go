@@ -0,0 +1,22 @@
+package models
+
+// DefaultStoreID is the outlet used when a request does not name one. It
+// holds all stock and history from before outlets existed.
+const DefaultStoreID = 1
+
+type Store struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Timezone string `json:"timezone"`
+}
+
+// StoreProduct is a product's stock and price at one outlet. A nil Price
+// means the outlet sells at the product's default price.
+type StoreProduct struct {
+	StoreID   int    `json:"store_id"`
+	StoreName string `json:"store_name,omitempty"`
+	ProductID int    `json:"product_id"`
+	Stock     int    `json:"stock"`
+	Price     *int   `json:"price"`
+}
@@ -2,8 +2,23 @@ package models
 
 type Transaction struct {
 	ID                 int                 `json:"id"`
+	StoreID            int                 `json:"store_id"`
+	CustomerID         *int                `json:"customer_id,omitempty"`
 	TotalAmount        int                 `json:"total_amount"`
 	TransactionDetails []TransactionDetail `json:"transaction_details,omitempty"`
+	// LowStock lists the products this transaction pushed to or below
+	// their reorder point.
+	LowStock []LowStockAlert `json:"low_stock,omitempty"`
+}
+
+// LowStockAlert is a product whose outlet stock crossed its reorder point
+// in a transaction. Stock is the balance the transaction left.
+type LowStockAlert struct {
+	ProductID       int    `json:"product_id"`
+	ProductName     string `json:"product_name"`
+	Stock           int    `json:"stock"`
+	ReorderPoint    int    `json:"reorder_point"`
+	ReorderQuantity int    `json:"reorder_quantity"`
 }
 
 type TransactionDetail struct {
@@ -12,14 +27,57 @@ type TransactionDetail struct {
 	ProductID     int    `json:"product_id"`
 	ProductName   string `json:"product_name"`
 	Quantity      int    `json:"quantity"`
+	UnitPrice     int    `json:"unit_price"`
 	Subtotal      int    `json:"subtotal"`
+	UnitCost      int    `json:"unit_cost"`
+	COGS          int    `json:"cogs"`
+
+	// TierMinQuantity is the minimum quantity of the price tier applied to
+	// this line, nil when the line sold at the regular price.
+	TierMinQuantity *int `json:"tier_min_quantity,omitempty"`
+
+	// PriceListID is the customer's price list the line was priced from,
+	// nil when the product is not on it.
+	PriceListID *int `json:"price_list_id,omitempty"`
+
+	Lots []LotUsage `json:"lots,omitempty"`
+
+	// Components lists the stock a bundle line used. Revenue stays on the
+	// bundle; COGS is the sum of its components'.
+	Components []DetailComponent `json:"components,omitempty"`
+
+	// Modifiers are the options picked on the line. ModifierPrice is their
+	// total per unit, charged on top of UnitPrice.
+	Modifiers     []DetailModifier `json:"modifiers,omitempty"`
+	ModifierPrice int              `json:"modifier_price"`
+
+	// OpenPrice is set when the cashier entered UnitPrice at checkout.
+	OpenPrice bool `json:"open_price,omitempty"`
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	// StoreID is the outlet of the register. Stock is taken from and
+	// prices are resolved for this outlet.
+	StoreID int            `json:"store_id"`
+	Items   []CheckoutItem `json:"items"`
+
+	// CustomerID prices the items from the price list of the customer's
+	// group. Zero sells at regular prices.
+	CustomerID int `json:"customer_id"`
+
+	// AllowExpired lets expired lots be sold. It needs a valid manager key.
+	AllowExpired bool `json:"allow_expired"`
 }
 
 type CheckoutItem struct {
 	ProductID int `json:"product_id"`
 	Quantity  int `json:"quantity"`
+
+	// ModifierIDs are the options picked from the product's modifier
+	// groups.
+	ModifierIDs []int `json:"modifier_ids,omitempty"`
+
+	// Price is the unit price entered for an open-price product. It is
+	// left out for other products.
+	Price *int `json:"price,omitempty"`
 }
@@ -0,0 +1,33 @@
+package notifiers
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+)
+
+// EmailNotifier sends plain-text mail without authentication, meant for a
+// local SMTP relay or a stand-in such as MailHog.
+type EmailNotifier struct {
+	addr string
+	from string
+	to   []string
+}
+
+func NewEmailNotifier(addr, from string, to []string) *EmailNotifier {
+	return &EmailNotifier{addr: addr, from: from, to: to}
+}
+
+// Notify sends the alert. The subject carries product names, so it is
+// encoded as a MIME word: a CR or LF in it cannot start a new header.
+func (n *EmailNotifier) Notify(subject, message string) error {
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		n.from,
+		strings.Join(n.to, ", "),
+		mime.QEncoding.Encode("utf-8", subject),
+		message,
+	)
+
+	return smtp.SendMail(n.addr, nil, n.from, n.to, []byte(msg))
+}
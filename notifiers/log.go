@@ -0,0 +1,14 @@
+package notifiers
+
+import "log"
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(subject, message string) error {
+	log.Printf("[NOTIFY] subject=%q message=%q", subject, message)
+	return nil
+}
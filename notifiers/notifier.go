@@ -0,0 +1,6 @@
+package notifiers
+
+// Notifier delivers operational alerts such as low-stock warnings.
+type Notifier interface {
+	Notify(subject, message string) error
+}
@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+)
+
+type BundleRepository struct {
+	db *sql.DB
+}
+
+func NewBundleRepository(db *sql.DB) *BundleRepository {
+	return &BundleRepository{db: db}
+}
+
+// FindByProductId lists the components of the bundle with their stock at
+// one outlet, or in total when storeId is zero.
+func (repo *BundleRepository) FindByProductId(productId int, storeId int) ([]models.BundleComponent, error) {
+	query := `
+		SELECT bi.component_id, c.name, bi.quantity, c.unit,
+			CASE WHEN $2 = 0 THEN c.stock ELSE COALESCE(sp.stock, 0) END
+		FROM product_bundle_items bi
+		JOIN products c ON c.id = bi.component_id
+		LEFT JOIN store_products sp ON sp.product_id = c.id AND sp.store_id = $2
+		WHERE bi.bundle_id = $1
+		ORDER BY c.name ASC
+	`
+
+	rows, err := repo.db.Query(query, productId, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := make([]models.BundleComponent, 0)
+	for rows.Next() {
+		var component models.BundleComponent
+		err := rows.Scan(&component.ProductID, &component.ProductName, &component.Quantity, &component.Unit, &component.Stock)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+
+	return components, nil
+}
+
+// Replace sets the components of the product. A product becomes a bundle
+// with its first components, which needs it to hold no stock of its own,
+// and a normal product again when they are all removed.
+func (repo *BundleRepository) Replace(productId int, components []models.BundleComponent) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stock int
+	var isBundle, isIngredient bool
+	var productType string
+	err = tx.QueryRow("SELECT stock, is_bundle, is_ingredient, type FROM products WHERE id = $1 FOR UPDATE", productId).Scan(&stock, &isBundle, &isIngredient, &productType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("product id %d not found", productId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if isIngredient && len(components) > 0 {
+		return fmt.Errorf("product id %d is an ingredient and cannot have components", productId)
+	}
+
+	if productType != models.ProductTypeStocked && len(components) > 0 {
+		return fmt.Errorf("product id %d is a %s product and cannot have components", productId, productType)
+	}
+
+	if !isBundle && len(components) > 0 && stock != 0 {
+		return fmt.Errorf("product id %d still has %d in stock; adjust it to 0 before making it a bundle", productId, stock)
+	}
+
+	_, err = tx.Exec("DELETE FROM product_bundle_items WHERE bundle_id = $1", productId)
+	if err != nil {
+		return err
+	}
+
+	if err := insertBundleComponents(tx, productId, components); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE products SET is_bundle = $1 WHERE id = $2", len(components) > 0, productId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertBundleComponents adds the components of the bundle. Bundles do not
+// nest: a component cannot be a bundle, nor can a component of another
+// bundle become one.
+func insertBundleComponents(tx *sql.Tx, bundleID int, components []models.BundleComponent) error {
+	if len(components) == 0 {
+		return nil
+	}
+
+	var isComponent bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_bundle_items WHERE component_id = $1)", bundleID).Scan(&isComponent)
+	if err != nil {
+		return err
+	}
+
+	if isComponent {
+		return fmt.Errorf("product id %d is a component of another bundle and cannot be a bundle itself", bundleID)
+	}
+
+	for i := range components {
+		var isBundle bool
+		var archived bool
+		var productType string
+		query := "SELECT name, is_bundle, archived_at IS NOT NULL, type FROM products WHERE id = $1"
+		err := tx.QueryRow(query, components[i].ProductID).Scan(&components[i].ProductName, &isBundle, &archived, &productType)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("component product id %d not found", components[i].ProductID)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if isBundle {
+			return fmt.Errorf("component %s is a bundle; bundles cannot contain bundles", components[i].ProductName)
+		}
+
+		if archived {
+			return fmt.Errorf("component %s is archived", components[i].ProductName)
+		}
+
+		if productType != models.ProductTypeStocked {
+			return fmt.Errorf("component %s is a %s product and holds no stock", components[i].ProductName, productType)
+		}
+
+		_, err = tx.Exec("INSERT INTO product_bundle_items (bundle_id, component_id, quantity) VALUES ($1, $2, $3)",
+			bundleID, components[i].ProductID, components[i].Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bundleComponent is a component locked for a sale, with what checkout
+// needs to take its stock.
+type bundleComponent struct {
+	models.BundleComponent
+	Cost int
+}
+
+// lockBundleComponents locks and returns the components of the bundle
+// with their stock at the outlet and current average cost.
+func lockBundleComponents(tx *sql.Tx, storeID, bundleID int) ([]bundleComponent, error) {
+	query := `
+		SELECT c.id, c.name, bi.quantity, COALESCE(sp.stock, 0), c.cost, c.archived_at IS NOT NULL
+		FROM product_bundle_items bi
+		JOIN products c ON c.id = bi.component_id
+		LEFT JOIN store_products sp ON sp.product_id = c.id AND sp.store_id = $2
+		WHERE bi.bundle_id = $1
+		ORDER BY c.id ASC
+		FOR UPDATE OF c
+	`
+
+	rows, err := tx.Query(query, bundleID, storeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var components []bundleComponent
+	for rows.Next() {
+		var component bundleComponent
+		var archived bool
+		err := rows.Scan(&component.ProductID, &component.ProductName, &component.Quantity, &component.Stock, &component.Cost, &archived)
+		if err != nil {
+			return nil, err
+		}
+
+		if archived {
+			return nil, fmt.Errorf("component %s of bundle product id %d is archived and cannot be sold", component.ProductName, bundleID)
+		}
+
+		components = append(components, component)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(components) == 0 {
+		return nil, fmt.Errorf("bundle product id %d has no components", bundleID)
+	}
+
+	return components, nil
+}
@@ -15,8 +15,37 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
+// categorySubtree returns a subquery listing the id of the category given
+// by the placeholder and the ids of every category below it.
+func categorySubtree(placeholder string) string {
+	return `(
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ` + placeholder + `
+			UNION ALL
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree
+	)`
+}
+
+func scanCategory(row interface{ Scan(...any) error }, category *models.Category) error {
+	var parentID sql.NullInt64
+
+	err := row.Scan(&category.ID, &category.Name, &category.Description, &parentID)
+	if err != nil {
+		return err
+	}
+
+	if parentID.Valid {
+		v := int(parentID.Int64)
+		category.ParentID = &v
+	}
+
+	return nil
+}
+
 func (repo *CategoryRepository) FindAll() ([]models.Category, error) {
-	query := "SELECT id, name, description FROM categories ORDER BY name ASC"
+	query := "SELECT id, name, description, parent_id FROM categories ORDER BY name ASC"
 
 	rows, err := repo.db.Query(query)
 	if err != nil {
@@ -27,8 +56,7 @@ func (repo *CategoryRepository) FindAll() ([]models.Category, error) {
 	categories := make([]models.Category, 0)
 	for rows.Next() {
 		var category models.Category
-		err := rows.Scan(&category.ID, &category.Name, &category.Description)
-		if err != nil {
+		if err := scanCategory(rows, &category); err != nil {
 			return nil, err
 		}
 		categories = append(categories, category)
@@ -37,19 +65,110 @@ func (repo *CategoryRepository) FindAll() ([]models.Category, error) {
 	return categories, nil
 }
 
+// FindSummaries lists a page of categories with their product counts and
+// stock value, over the whole subtree and for the category alone. Bundles
+// are counted but add no stock value, as their stock belongs to their
+// components.
+func (repo *CategoryRepository) FindSummaries(filter models.CategoryFilter) ([]models.CategorySummary, error) {
+	source, args := productSource(filter.StoreID)
+
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	order := "c.name " + direction + ", c.id " + direction
+	switch filter.Sort {
+	case models.CategorySortProductCount:
+		order = "product_count " + direction + ", c.name ASC, c.id ASC"
+	case models.CategorySortStockValue:
+		order = "stock_value " + direction + ", c.name ASC, c.id ASC"
+	}
+
+	args = append(args, models.ProductTypeStocked)
+	stocked := fmt.Sprintf("$%d", len(args))
+
+	// tree pairs every category with itself and each category below it
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id AS root_id, id FROM categories
+			UNION ALL
+			SELECT t.root_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+		),
+		direct AS (
+			SELECT c.id, COUNT(products.id) AS product_count,
+				COALESCE(SUM(CASE WHEN NOT products.is_bundle AND products.type = ` + stocked + ` THEN products.stock * products.cost END), 0) AS stock_value
+			FROM categories c
+			LEFT JOIN ` + source + ` ON products.category_id = c.id AND products.archived_at IS NULL
+			GROUP BY c.id
+		)
+		SELECT c.id, c.name, c.description, c.parent_id, SUM(sub.product_count) AS product_count, SUM(sub.stock_value) AS stock_value,
+			d.product_count, d.stock_value
+		FROM categories c
+		JOIN direct d ON d.id = c.id
+		JOIN tree t ON t.root_id = c.id
+		JOIN direct sub ON sub.id = t.id
+		GROUP BY c.id, c.name, c.description, c.parent_id, d.product_count, d.stock_value
+		ORDER BY ` + order
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]models.CategorySummary, 0)
+	for rows.Next() {
+		var summary models.CategorySummary
+		var parentID sql.NullInt64
+		err := rows.Scan(&summary.ID, &summary.Name, &summary.Description, &parentID, &summary.ProductCount, &summary.StockValue,
+			&summary.DirectProductCount, &summary.DirectStockValue)
+		if err != nil {
+			return nil, err
+		}
+
+		if parentID.Valid {
+			v := int(parentID.Int64)
+			summary.ParentID = &v
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// Count returns the number of categories.
+func (repo *CategoryRepository) Count() (int, error) {
+	var total int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM categories").Scan(&total)
+
+	return total, err
+}
+
 func (repo *CategoryRepository) Create(category *models.Category) error {
-	query := "INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id"
+	query := "INSERT INTO categories (name, description, parent_id) VALUES ($1, $2, $3) RETURNING id"
 
-	err := repo.db.QueryRow(query, category.Name, category.Description).Scan(&category.ID)
+	err := repo.db.QueryRow(query, category.Name, category.Description, category.ParentID).Scan(&category.ID)
 
 	return err
 }
 
 func (repo *CategoryRepository) FindById(id int) (*models.Category, error) {
-	query := "SELECT id, name, description FROM categories WHERE id = $1"
+	query := "SELECT id, name, description, parent_id FROM categories WHERE id = $1"
 
 	var category models.Category
-	err := repo.db.QueryRow(query, id).Scan(&category.ID, &category.Name, &category.Description)
+	err := scanCategory(repo.db.QueryRow(query, id), &category)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("category id %d not found", id)
 	}
@@ -61,10 +180,73 @@ func (repo *CategoryRepository) FindById(id int) (*models.Category, error) {
 	return &category, nil
 }
 
+// FindPath returns the categories from the top level down to the category.
+func (repo *CategoryRepository) FindPath(id int) ([]models.CategoryCrumb, error) {
+	query := `
+		WITH RECURSIVE path AS (
+			SELECT id, name, parent_id, 0 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.parent_id, p.depth + 1 FROM categories c JOIN path p ON c.id = p.parent_id
+		)
+		SELECT id, name FROM path ORDER BY depth DESC
+	`
+
+	rows, err := repo.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	path := make([]models.CategoryCrumb, 0)
+	for rows.Next() {
+		var crumb models.CategoryCrumb
+		if err := rows.Scan(&crumb.ID, &crumb.Name); err != nil {
+			return nil, err
+		}
+		path = append(path, crumb)
+	}
+
+	return path, nil
+}
+
+// Update changes the name and description of the category. Its place in
+// the tree only changes through Move.
 func (repo *CategoryRepository) Update(category *models.Category) error {
-	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3"
+	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3 RETURNING parent_id"
+
+	var parentID sql.NullInt64
+	err := repo.db.QueryRow(query, category.Name, category.Description, category.ID).Scan(&parentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("category not found")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	category.ParentID = nil
+	if parentID.Valid {
+		v := int(parentID.Int64)
+		category.ParentID = &v
+	}
+
+	return nil
+}
+
+// Move puts the category, with its subtree, under parentID, or at the top
+// level when parentID is nil.
+func (repo *CategoryRepository) Move(id int, parentID *int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkCategoryParent(tx, id, parentID); err != nil {
+		return err
+	}
 
-	result, err := repo.db.Exec(query, category.Name, category.Description, category.ID)
+	result, err := tx.Exec("UPDATE categories SET parent_id = $1 WHERE id = $2", parentID, id)
 	if err != nil {
 		return err
 	}
@@ -78,10 +260,32 @@ func (repo *CategoryRepository) Update(category *models.Category) error {
 		return fmt.Errorf("category not found")
 	}
 
-	return nil
+	return tx.Commit()
 }
 
+// HasChildren reports whether any category sits directly under the
+// category.
+func (repo *CategoryRepository) HasChildren(id int) (bool, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)", id).Scan(&exists)
+
+	return exists, err
+}
+
+// Delete removes an empty category. Its price list rules go with it; a
+// category that stock counts were taken of is kept, as the counts would
+// lose what they covered.
 func (repo *CategoryRepository) Delete(id int) error {
+	var counts int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM stock_counts WHERE category_id = $1", id).Scan(&counts)
+	if err != nil {
+		return err
+	}
+
+	if counts > 0 {
+		return fmt.Errorf("cannot delete category: %d stock counts were taken of it, delete it with move_to", counts)
+	}
+
 	query := "DELETE FROM categories WHERE id = $1"
 
 	result, err := repo.db.Exec(query, id)
@@ -100,3 +304,148 @@ func (repo *CategoryRepository) Delete(id int) error {
 
 	return nil
 }
+
+// DeleteMovingProducts moves every product of the category, archived ones
+// included, to the target category and deletes the category, all in one
+// transaction. The category's price list rules become product rules for
+// the products moved, unless a list already has its own rule for one, and
+// its stock counts are filed under the target. It returns how many
+// products were moved.
+func (repo *CategoryRepository) DeleteMovingProducts(id, targetID int) (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// lock both rows in id order so opposite moves cannot deadlock
+	rows, err := tx.Query("SELECT id FROM categories WHERE id IN ($1, $2) ORDER BY id FOR UPDATE", id, targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	found := make(map[int]bool)
+	for rows.Next() {
+		var categoryID int
+		if err := rows.Scan(&categoryID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		found[categoryID] = true
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if !found[id] {
+		return 0, fmt.Errorf("category not found")
+	}
+
+	if !found[targetID] {
+		return 0, fmt.Errorf("target category id %d not found", targetID)
+	}
+
+	var hasChildren bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)", id).Scan(&hasChildren)
+	if err != nil {
+		return 0, err
+	}
+
+	if hasChildren {
+		return 0, fmt.Errorf("cannot delete category: category still has subcategories")
+	}
+
+	priceQuery := `
+		INSERT INTO price_list_items (price_list_id, product_id, fixed_price, discount_percent)
+		SELECT i.price_list_id, p.id, i.fixed_price, i.discount_percent
+		FROM price_list_items i
+		JOIN products p ON p.category_id = i.category_id
+		WHERE i.category_id = $1
+			AND NOT EXISTS (SELECT 1 FROM price_list_items o WHERE o.price_list_id = i.price_list_id AND o.product_id = p.id)
+	`
+
+	_, err = tx.Exec(priceQuery, id)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("DELETE FROM price_list_items WHERE category_id = $1", id)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("UPDATE stock_counts SET category_id = $1 WHERE category_id = $2", targetID, id)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("UPDATE products SET category_id = $1 WHERE category_id = $2", targetID, id)
+	if err != nil {
+		return 0, err
+	}
+
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("DELETE FROM categories WHERE id = $1", id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int(moved), nil
+}
+
+// checkCategoryParent makes sure parentID exists and is not the category
+// itself or below it, which would make a cycle. The categories on the new
+// parent's path are locked so concurrent moves cannot form one either.
+func checkCategoryParent(tx *sql.Tx, id int, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
+
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT id FROM categories WHERE id IN (SELECT id FROM ancestors) FOR UPDATE
+	`
+
+	rows, err := tx.Query(query, *parentID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	found := false
+	for rows.Next() {
+		var ancestorID int
+		if err := rows.Scan(&ancestorID); err != nil {
+			return err
+		}
+
+		if ancestorID == id {
+			return fmt.Errorf("category id %d cannot be moved under itself or one of its subcategories", id)
+		}
+		found = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("parent category id %d not found", *parentID)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-go/models"
+)
+
+// movingAverageCost blends quantity incoming units costing totalCost into
+// the current average cost. Negative stock on hand carries no value.
+func movingAverageCost(stock, cost, quantity, totalCost int) int {
+	if stock < 0 {
+		stock = 0
+	}
+
+	total := stock + quantity
+	if total == 0 {
+		return cost
+	}
+
+	return (stock*cost + totalCost + total/2) / total
+}
+
+// openCostLayers adds the inbound movement's units as cost layers at the
+// movement's outlet. When movement.Cost does not divide evenly, the
+// remainder goes to a second layer one Rupiah dearer so the layers add up
+// to the exact cost.
+func openCostLayers(tx *sql.Tx, movement *models.StockMovement) error {
+	unitCost := movement.Cost / movement.Quantity
+	dearer := movement.Cost % movement.Quantity
+
+	query := "INSERT INTO cost_layers (store_id, product_id, quantity, remaining, unit_cost, stock_movement_id) VALUES ($1, $2, $3, $3, $4, $5)"
+
+	if cheaper := movement.Quantity - dearer; cheaper > 0 {
+		_, err := tx.Exec(query, movement.StoreID, movement.ProductID, cheaper, unitCost, movement.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dearer > 0 {
+		_, err := tx.Exec(query, movement.StoreID, movement.ProductID, dearer, unitCost+1, movement.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// consumeCostLayers takes quantity units from the oldest cost layers of
+// the product at the outlet and returns their total cost. Stock from before
+// costing sits in the opening layers seeded by migrations 021 and 023.
+// Units beyond every layer, sold into negative stock, are valued at
+// fallbackCost.
+func consumeCostLayers(tx *sql.Tx, storeID, productID, quantity, fallbackCost int) (int, error) {
+	type layer struct {
+		id, remaining, unitCost int
+	}
+
+	query := `
+		SELECT id, remaining, unit_cost FROM cost_layers
+		WHERE store_id = $1 AND product_id = $2 AND remaining > 0
+		ORDER BY created_at ASC, id ASC
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(query, storeID, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	layers := make([]layer, 0)
+	for rows.Next() {
+		var l layer
+		if err := rows.Scan(&l.id, &l.remaining, &l.unitCost); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		layers = append(layers, l)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, l := range layers {
+		if quantity == 0 {
+			break
+		}
+
+		take := min(l.remaining, quantity)
+
+		_, err := tx.Exec("UPDATE cost_layers SET remaining = remaining - $1 WHERE id = $2", take, l.id)
+		if err != nil {
+			return 0, err
+		}
+
+		total += take * l.unitCost
+		quantity -= take
+	}
+
+	return total + quantity*fallbackCost, nil
+}
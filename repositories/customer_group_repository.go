@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+)
+
+type CustomerGroupRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerGroupRepository(db *sql.DB) *CustomerGroupRepository {
+	return &CustomerGroupRepository{db: db}
+}
+
+const customerGroupColumns = "g.id, g.name, g.price_list_id, COALESCE(l.name, '')"
+
+func scanCustomerGroup(row interface{ Scan(...any) error }, group *models.CustomerGroup) error {
+	var priceListID sql.NullInt64
+
+	err := row.Scan(&group.ID, &group.Name, &priceListID, &group.PriceListName)
+	if err != nil {
+		return err
+	}
+
+	if priceListID.Valid {
+		v := int(priceListID.Int64)
+		group.PriceListID = &v
+	}
+
+	return nil
+}
+
+func (repo *CustomerGroupRepository) FindAll() ([]models.CustomerGroup, error) {
+	query := `
+		SELECT ` + customerGroupColumns + `
+		FROM customer_groups g
+		LEFT JOIN price_lists l ON l.id = g.price_list_id
+		ORDER BY g.name ASC
+	`
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.CustomerGroup, 0)
+	for rows.Next() {
+		var group models.CustomerGroup
+		if err := scanCustomerGroup(rows, &group); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+func (repo *CustomerGroupRepository) Create(group *models.CustomerGroup) error {
+	query := "INSERT INTO customer_groups (name, price_list_id) VALUES ($1, $2) RETURNING id"
+
+	err := repo.db.QueryRow(query, group.Name, group.PriceListID).Scan(&group.ID)
+
+	return err
+}
+
+func (repo *CustomerGroupRepository) FindById(id int) (*models.CustomerGroup, error) {
+	query := `
+		SELECT ` + customerGroupColumns + `
+		FROM customer_groups g
+		LEFT JOIN price_lists l ON l.id = g.price_list_id
+		WHERE g.id = $1
+	`
+
+	var group models.CustomerGroup
+	err := scanCustomerGroup(repo.db.QueryRow(query, id), &group)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("customer group id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (repo *CustomerGroupRepository) Update(group *models.CustomerGroup) error {
+	query := "UPDATE customer_groups SET name = $1, price_list_id = $2 WHERE id = $3"
+
+	result, err := repo.db.Exec(query, group.Name, group.PriceListID, group.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("customer group not found")
+	}
+
+	return nil
+}
+
+func (repo *CustomerGroupRepository) Delete(id int) error {
+	query := "DELETE FROM customer_groups WHERE id = $1"
+
+	result, err := repo.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("customer group not found")
+	}
+
+	return nil
+}
+
+func (repo *CustomerGroupRepository) HasCustomers(id int) (bool, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM customers WHERE group_id = $1)", id).Scan(&exists)
+
+	return exists, err
+}
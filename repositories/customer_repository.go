@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+const customerColumns = "c.id, c.name, c.phone, c.email, c.group_id, COALESCE(g.name, ''), c.created_at"
+
+func scanCustomer(row interface{ Scan(...any) error }, customer *models.Customer) error {
+	var groupID sql.NullInt64
+
+	err := row.Scan(&customer.ID, &customer.Name, &customer.Phone, &customer.Email, &groupID, &customer.GroupName, &customer.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if groupID.Valid {
+		v := int(groupID.Int64)
+		customer.GroupID = &v
+	}
+
+	return nil
+}
+
+func (repo *CustomerRepository) FindAll(name string, groupId int) ([]models.Customer, error) {
+	query := `
+		SELECT ` + customerColumns + `
+		FROM customers c
+		LEFT JOIN customer_groups g ON g.id = c.group_id
+		WHERE ($1 = '' OR c.name ILIKE '%' || $1 || '%' OR c.phone = $1) AND ($2 = 0 OR c.group_id = $2)
+		ORDER BY c.name ASC
+	`
+
+	rows, err := repo.db.Query(query, name, groupId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]models.Customer, 0)
+	for rows.Next() {
+		var customer models.Customer
+		if err := scanCustomer(rows, &customer); err != nil {
+			return nil, err
+		}
+		customers = append(customers, customer)
+	}
+
+	return customers, nil
+}
+
+func (repo *CustomerRepository) Create(customer *models.Customer) error {
+	query := "INSERT INTO customers (name, phone, email, group_id) VALUES ($1, $2, $3, $4) RETURNING id, created_at"
+
+	err := repo.db.QueryRow(query, customer.Name, customer.Phone, customer.Email, customer.GroupID).Scan(&customer.ID, &customer.CreatedAt)
+
+	return err
+}
+
+func (repo *CustomerRepository) FindById(id int) (*models.Customer, error) {
+	query := `
+		SELECT ` + customerColumns + `
+		FROM customers c
+		LEFT JOIN customer_groups g ON g.id = c.group_id
+		WHERE c.id = $1
+	`
+
+	var customer models.Customer
+	err := scanCustomer(repo.db.QueryRow(query, id), &customer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("customer id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+func (repo *CustomerRepository) Update(customer *models.Customer) error {
+	query := "UPDATE customers SET name = $1, phone = $2, email = $3, group_id = $4 WHERE id = $5 RETURNING created_at"
+
+	err := repo.db.QueryRow(query, customer.Name, customer.Phone, customer.Email, customer.GroupID, customer.ID).Scan(&customer.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("customer not found")
+	}
+
+	return err
+}
+
+func (repo *CustomerRepository) Delete(id int) error {
+	query := "DELETE FROM customers WHERE id = $1"
+
+	result, err := repo.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("customer not found")
+	}
+
+	return nil
+}
+
+func (repo *CustomerRepository) HasTransactions(id int) (bool, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM transactions WHERE customer_id = $1)", id).Scan(&exists)
+
+	return exists, err
+}
+
+// findCustomerPriceList returns the price list of the customer's group
+// when it is valid at the time of the transaction, or nil.
+func findCustomerPriceList(tx *sql.Tx, customerID int) (*int, error) {
+	query := `
+		SELECT l.id
+		FROM customers c
+		LEFT JOIN customer_groups g ON g.id = c.group_id
+		LEFT JOIN price_lists l ON l.id = g.price_list_id
+			AND (l.valid_from IS NULL OR l.valid_from <= NOW())
+			AND (l.valid_until IS NULL OR l.valid_until > NOW())
+		WHERE c.id = $1
+	`
+
+	var priceListID sql.NullInt64
+	err := tx.QueryRow(query, customerID).Scan(&priceListID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("customer id %d not found", customerID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !priceListID.Valid {
+		return nil, nil
+	}
+
+	id := int(priceListID.Int64)
+	return &id, nil
+}
@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+)
+
+type LotRepository struct {
+	db *sql.DB
+}
+
+func NewLotRepository(db *sql.DB) *LotRepository {
+	return &LotRepository{db: db}
+}
+
+// lotColumns expects product_lots aliased as l and products as p. Expiry is
+// compared against the calendar date in Asia/Jakarta.
+const lotColumns = `l.id, l.product_id, p.name, l.store_id, l.lot_number, to_char(l.expiry_date, 'YYYY-MM-DD'),
+	l.expiry_date - (NOW() AT TIME ZONE 'Asia/Jakarta')::date, l.quantity, p.cost, l.created_at`
+
+func scanLot(row interface{ Scan(...any) error }, lot *models.ProductLot) error {
+	return row.Scan(&lot.ID, &lot.ProductID, &lot.ProductName, &lot.StoreID, &lot.LotNumber, &lot.ExpiryDate, &lot.DaysLeft, &lot.Quantity, &lot.UnitCost, &lot.CreatedAt)
+}
+
+// FindByProductId lists the product's lots with stock left, limited to one
+// outlet when storeId is not zero.
+func (repo *LotRepository) FindByProductId(productId int, storeId int) ([]models.ProductLot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM product_lots l
+		JOIN products p ON p.id = l.product_id
+		WHERE l.product_id = $1 AND l.quantity > 0 AND ($2 = 0 OR l.store_id = $2)
+		ORDER BY l.expiry_date ASC, l.id ASC
+	`
+
+	rows, err := repo.db.Query(query, productId, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make([]models.ProductLot, 0)
+	for rows.Next() {
+		var lot models.ProductLot
+		if err := scanLot(rows, &lot); err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	return lots, nil
+}
+
+// FindExpiring returns lots with stock left that expire within the given
+// number of days, including lots that have already expired. A zero
+// storeId covers all outlets.
+func (repo *LotRepository) FindExpiring(withinDays int, storeId int) ([]models.ProductLot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM product_lots l
+		JOIN products p ON p.id = l.product_id
+		WHERE l.quantity > 0 AND l.expiry_date <= (NOW() AT TIME ZONE 'Asia/Jakarta')::date + $1::int
+			AND ($2 = 0 OR l.store_id = $2)
+		ORDER BY l.expiry_date ASC, p.name ASC
+	`
+
+	rows, err := repo.db.Query(query, withinDays, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make([]models.ProductLot, 0)
+	for rows.Next() {
+		var lot models.ProductLot
+		if err := scanLot(rows, &lot); err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	return lots, nil
+}
+
+// Create assigns part of the product's untracked stock at the lot's outlet
+// to a lot. It does not change the stock itself.
+func (repo *LotRepository) Create(lot *models.ProductLot) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stock, tracked int
+	err = tx.QueryRow("SELECT stock FROM store_products WHERE store_id = $1 AND product_id = $2 FOR UPDATE", lot.StoreID, lot.ProductID).Scan(&stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("product id %d has no stock at store id %d", lot.ProductID, lot.StoreID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM product_lots WHERE store_id = $1 AND product_id = $2", lot.StoreID, lot.ProductID).Scan(&tracked)
+	if err != nil {
+		return err
+	}
+
+	if lot.Quantity > stock-tracked {
+		return fmt.Errorf("only %d units of product id %d at store id %d are not assigned to a lot", max(stock-tracked, 0), lot.ProductID, lot.StoreID)
+	}
+
+	lot.ID, err = addToLot(tx, lot.StoreID, lot.ProductID, lot.LotNumber, lot.ExpiryDate, lot.Quantity)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addToLot creates the lot or tops it up when the lot number already
+// exists for the product at the outlet. A lot holds one expiry date, so
+// topping up with a different date is refused; a lot that has run out
+// takes the new date, as suppliers reuse lot numbers.
+func addToLot(tx *sql.Tx, storeID, productID int, lotNumber, expiryDate string, quantity int) (int, error) {
+	query := `
+		INSERT INTO product_lots (store_id, product_id, lot_number, expiry_date, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (store_id, product_id, lot_number) DO UPDATE SET
+			quantity = product_lots.quantity + EXCLUDED.quantity,
+			expiry_date = CASE WHEN product_lots.quantity > 0 THEN product_lots.expiry_date ELSE EXCLUDED.expiry_date END
+		RETURNING id, to_char(expiry_date, 'YYYY-MM-DD')
+	`
+
+	var id int
+	var lotExpiry string
+	err := tx.QueryRow(query, storeID, productID, lotNumber, expiryDate, quantity).Scan(&id, &lotExpiry)
+	if err != nil {
+		return 0, err
+	}
+
+	if lotExpiry != expiryDate {
+		return 0, fmt.Errorf("lot %s of product id %d expires on %s, not %s", lotNumber, productID, lotExpiry, expiryDate)
+	}
+
+	return id, nil
+}
+
+// takeFromLots removes up to quantity units from the product's lots at the
+// outlet in first-expired-first-out order. Expired lots are skipped unless
+// includeExpired is set. It returns what was taken from each lot.
+func takeFromLots(tx *sql.Tx, storeID, productID, quantity int, includeExpired bool) ([]models.LotUsage, error) {
+	query := `
+		SELECT id, lot_number, to_char(expiry_date, 'YYYY-MM-DD'), quantity
+		FROM product_lots
+		WHERE store_id = $1 AND product_id = $2 AND quantity > 0
+			AND ($3 OR expiry_date >= (NOW() AT TIME ZONE 'Asia/Jakarta')::date)
+		ORDER BY expiry_date ASC, id ASC
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(query, storeID, productID, includeExpired)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]models.LotUsage, 0)
+	for rows.Next() {
+		var lot models.LotUsage
+		if err := rows.Scan(&lot.LotID, &lot.LotNumber, &lot.ExpiryDate, &lot.Quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		available = append(available, lot)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	usages := make([]models.LotUsage, 0)
+	for _, lot := range available {
+		if quantity == 0 {
+			break
+		}
+
+		take := min(lot.Quantity, quantity)
+
+		_, err := tx.Exec("UPDATE product_lots SET quantity = quantity - $1 WHERE id = $2", take, lot.LotID)
+		if err != nil {
+			return nil, err
+		}
+
+		lot.Quantity = take
+		usages = append(usages, lot)
+		quantity -= take
+	}
+
+	return usages, nil
+}
+
+// trimLots keeps the lots from holding more than the product's stock after
+// a decrease that did not pick lots itself, such as an adjustment. The
+// excess is taken first-expired-first-out.
+func trimLots(tx *sql.Tx, storeID, productID, stock int) error {
+	var tracked int
+	err := tx.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM product_lots WHERE store_id = $1 AND product_id = $2", storeID, productID).Scan(&tracked)
+	if err != nil {
+		return err
+	}
+
+	if excess := tracked - max(stock, 0); excess > 0 {
+		_, err = takeFromLots(tx, storeID, productID, excess, true)
+	}
+
+	return err
+}
+
+// pickLots takes a sold quantity from the product's lots, soonest expiry
+// first, and falls back to untracked stock. Expired lots are only sold
+// when allowExpired is set.
+func pickLots(tx *sql.Tx, storeID, productID int, productName string, quantity int, allowExpired bool) ([]models.LotUsage, error) {
+	usages, err := takeFromLots(tx, storeID, productID, quantity, allowExpired)
+	if err != nil {
+		return nil, err
+	}
+
+	picked := 0
+	for _, u := range usages {
+		picked += u.Quantity
+	}
+
+	if picked == quantity {
+		return usages, nil
+	}
+
+	var stock, tracked int
+	query := `
+		SELECT stock, COALESCE((SELECT SUM(quantity) FROM product_lots WHERE store_id = $1 AND product_id = $2), 0)
+		FROM store_products
+		WHERE store_id = $1 AND product_id = $2
+	`
+	err = tx.QueryRow(query, storeID, productID).Scan(&stock, &tracked)
+	if err != nil {
+		return nil, err
+	}
+
+	if untracked := stock - picked - tracked; quantity-picked > untracked {
+		return nil, fmt.Errorf("product %s: %d units are in expired lots, manager override required", productName, quantity-picked-max(untracked, 0))
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,339 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+
+	"github.com/lib/pq"
+)
+
+type ModifierGroupRepository struct {
+	db *sql.DB
+}
+
+func NewModifierGroupRepository(db *sql.DB) *ModifierGroupRepository {
+	return &ModifierGroupRepository{db: db}
+}
+
+const modifierGroupColumns = "g.id, g.name, g.selection, g.min_select, g.max_select, g.created_at"
+
+func scanModifierGroup(row interface{ Scan(...any) error }, group *models.ModifierGroup) error {
+	return row.Scan(&group.ID, &group.Name, &group.Selection, &group.MinSelect, &group.MaxSelect, &group.CreatedAt)
+}
+
+func (repo *ModifierGroupRepository) FindAll() ([]models.ModifierGroup, error) {
+	query := "SELECT " + modifierGroupColumns + " FROM modifier_groups g ORDER BY g.name ASC"
+
+	return repo.findGroups(query)
+}
+
+func (repo *ModifierGroupRepository) FindById(id int) (*models.ModifierGroup, error) {
+	query := "SELECT " + modifierGroupColumns + " FROM modifier_groups g WHERE g.id = $1"
+
+	groups, err := repo.findGroups(query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("modifier group id %d not found", id)
+	}
+
+	return &groups[0], nil
+}
+
+// FindByProductId lists the groups attached to the product in the order
+// they are shown at the register.
+func (repo *ModifierGroupRepository) FindByProductId(productId int) ([]models.ModifierGroup, error) {
+	query := `
+		SELECT ` + modifierGroupColumns + `
+		FROM product_modifier_groups pg
+		JOIN modifier_groups g ON g.id = pg.group_id
+		WHERE pg.product_id = $1
+		ORDER BY pg.position ASC, g.id ASC
+	`
+
+	return repo.findGroups(query, productId)
+}
+
+// findGroups runs a query selecting modifierGroupColumns and fills in the
+// modifiers of every group found.
+func (repo *ModifierGroupRepository) findGroups(query string, args ...any) ([]models.ModifierGroup, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.ModifierGroup, 0)
+	index := make(map[int]int)
+	ids := make([]int, 0)
+	for rows.Next() {
+		var group models.ModifierGroup
+		if err := scanModifierGroup(rows, &group); err != nil {
+			return nil, err
+		}
+		group.Modifiers = make([]models.Modifier, 0)
+		index[group.ID] = len(groups)
+		ids = append(ids, group.ID)
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return groups, nil
+	}
+
+	modifierRows, err := repo.db.Query("SELECT id, group_id, name, price FROM modifiers WHERE group_id = ANY($1) ORDER BY position ASC, id ASC", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer modifierRows.Close()
+
+	for modifierRows.Next() {
+		var modifier models.Modifier
+		if err := modifierRows.Scan(&modifier.ID, &modifier.GroupID, &modifier.Name, &modifier.Price); err != nil {
+			return nil, err
+		}
+		group := &groups[index[modifier.GroupID]]
+		group.Modifiers = append(group.Modifiers, modifier)
+	}
+
+	return groups, nil
+}
+
+func (repo *ModifierGroupRepository) Create(group *models.ModifierGroup) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO modifier_groups (name, selection, min_select, max_select) VALUES ($1, $2, $3, $4) RETURNING id, created_at"
+
+	err = tx.QueryRow(query, group.Name, group.Selection, group.MinSelect, group.MaxSelect).Scan(&group.ID, &group.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := saveModifiers(tx, group); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the group's settings and modifiers. Modifiers sent with
+// their id keep it, so past sales still point at them; those left out are
+// removed.
+func (repo *ModifierGroupRepository) Update(group *models.ModifierGroup) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE modifier_groups SET name = $1, selection = $2, min_select = $3, max_select = $4 WHERE id = $5 RETURNING created_at"
+
+	err = tx.QueryRow(query, group.Name, group.Selection, group.MinSelect, group.MaxSelect, group.ID).Scan(&group.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("modifier group not found")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := saveModifiers(tx, group); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *ModifierGroupRepository) Delete(id int) error {
+	result, err := repo.db.Exec("DELETE FROM modifier_groups WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("modifier group not found")
+	}
+
+	return nil
+}
+
+// ReplaceForProduct attaches the groups to the product in the given order,
+// detaching any others.
+func (repo *ModifierGroupRepository) ReplaceForProduct(productId int, groupIds []int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM product_modifier_groups WHERE product_id = $1", productId)
+	if err != nil {
+		return err
+	}
+
+	for i, groupID := range groupIds {
+		var exists bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM modifier_groups WHERE id = $1)", groupID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("modifier group id %d not found", groupID)
+		}
+
+		_, err = tx.Exec("INSERT INTO product_modifier_groups (product_id, group_id, position) VALUES ($1, $2, $3)", productId, groupID, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// saveModifiers writes the modifiers of the group in their order and
+// removes the group's modifiers that are no longer listed.
+func saveModifiers(tx *sql.Tx, group *models.ModifierGroup) error {
+	kept := make([]int, 0, len(group.Modifiers))
+	for i := range group.Modifiers {
+		modifier := &group.Modifiers[i]
+		modifier.GroupID = group.ID
+
+		if modifier.ID == 0 {
+			query := "INSERT INTO modifiers (group_id, name, price, position) VALUES ($1, $2, $3, $4) RETURNING id"
+			err := tx.QueryRow(query, group.ID, modifier.Name, modifier.Price, i).Scan(&modifier.ID)
+			if err != nil {
+				return err
+			}
+		} else {
+			query := "UPDATE modifiers SET name = $1, price = $2, position = $3 WHERE id = $4 AND group_id = $5"
+			result, err := tx.Exec(query, modifier.Name, modifier.Price, i, modifier.ID, group.ID)
+			if err != nil {
+				return err
+			}
+
+			rows, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if rows == 0 {
+				return fmt.Errorf("modifier id %d is not in modifier group id %d", modifier.ID, group.ID)
+			}
+		}
+
+		kept = append(kept, modifier.ID)
+	}
+
+	_, err := tx.Exec("DELETE FROM modifiers WHERE group_id = $1 AND NOT (id = ANY($2))", group.ID, pq.Array(kept))
+
+	return err
+}
+
+// pickModifiers checks the modifiers picked on a checkout line against the
+// product's groups and returns them with their total price per unit.
+func pickModifiers(tx *sql.Tx, productID int, productName string, modifierIDs []int) ([]models.DetailModifier, int, error) {
+	seen := make(map[int]bool)
+	for _, id := range modifierIDs {
+		if seen[id] {
+			return nil, 0, fmt.Errorf("modifier id %d is picked more than once for product %s", id, productName)
+		}
+		seen[id] = true
+	}
+
+	query := `
+		SELECT g.id, g.name, g.min_select, g.max_select, m.id, m.name, m.price
+		FROM product_modifier_groups pg
+		JOIN modifier_groups g ON g.id = pg.group_id
+		LEFT JOIN modifiers m ON m.group_id = g.id AND m.id = ANY($2)
+		WHERE pg.product_id = $1
+		ORDER BY pg.position ASC, g.id ASC, m.position ASC, m.id ASC
+	`
+
+	rows, err := tx.Query(query, productID, pq.Array(modifierIDs))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	type groupPick struct {
+		name      string
+		minSelect int
+		maxSelect int
+		picked    int
+	}
+
+	var order []int
+	groups := make(map[int]*groupPick)
+	modifiers := make([]models.DetailModifier, 0, len(modifierIDs))
+	total := 0
+
+	for rows.Next() {
+		var groupID int
+		var group groupPick
+		var modifierID sql.NullInt64
+		var modifierName sql.NullString
+		var modifierPrice sql.NullInt64
+
+		err := rows.Scan(&groupID, &group.name, &group.minSelect, &group.maxSelect, &modifierID, &modifierName, &modifierPrice)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if _, ok := groups[groupID]; !ok {
+			groups[groupID] = &group
+			order = append(order, groupID)
+		}
+
+		if !modifierID.Valid {
+			continue
+		}
+
+		groups[groupID].picked++
+		total += int(modifierPrice.Int64)
+		modifiers = append(modifiers, models.DetailModifier{
+			ModifierID: int(modifierID.Int64),
+			GroupName:  group.name,
+			Name:       modifierName.String,
+			Price:      int(modifierPrice.Int64),
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(modifiers) != len(modifierIDs) {
+		return nil, 0, fmt.Errorf("some modifiers are not offered on product %s", productName)
+	}
+
+	for _, groupID := range order {
+		group := groups[groupID]
+		if group.picked < group.minSelect {
+			return nil, 0, fmt.Errorf("product %s needs at least %d choice(s) of %s", productName, group.minSelect, group.name)
+		}
+
+		if group.maxSelect > 0 && group.picked > group.maxSelect {
+			return nil, 0, fmt.Errorf("product %s allows at most %d choice(s) of %s", productName, group.maxSelect, group.name)
+		}
+	}
+
+	return modifiers, total, nil
+}
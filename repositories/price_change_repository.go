@@ -0,0 +1,263 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PriceChangeRepository struct {
+	db *sql.DB
+}
+
+func NewPriceChangeRepository(db *sql.DB) *PriceChangeRepository {
+	return &PriceChangeRepository{db: db}
+}
+
+const priceChangeColumns = `id, product_id, old_price, new_price, status, effective_at,
+	COALESCE(created_by, ''), created_at, applied_at`
+
+func scanPriceChange(row interface{ Scan(...any) error }, change *models.PriceChange) error {
+	var oldPrice sql.NullInt64
+	var appliedAt sql.NullTime
+
+	err := row.Scan(&change.ID, &change.ProductID, &oldPrice, &change.NewPrice, &change.Status, &change.EffectiveAt,
+		&change.CreatedBy, &change.CreatedAt, &appliedAt)
+	if err != nil {
+		return err
+	}
+
+	if oldPrice.Valid {
+		v := int(oldPrice.Int64)
+		change.OldPrice = &v
+	}
+
+	if appliedAt.Valid {
+		change.AppliedAt = &appliedAt.Time
+	}
+
+	return nil
+}
+
+// FindByProductId lists the product's price changes with the given status,
+// in the order they take effect.
+func (repo *PriceChangeRepository) FindByProductId(productId int, status string) ([]models.PriceChange, error) {
+	query := "SELECT " + priceChangeColumns + " FROM price_changes WHERE product_id = $1 AND status = $2 ORDER BY effective_at ASC, id ASC"
+
+	rows, err := repo.db.Query(query, productId, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]models.PriceChange, 0)
+	for rows.Next() {
+		var change models.PriceChange
+		if err := scanPriceChange(rows, &change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func (repo *PriceChangeRepository) Schedule(change *models.PriceChange) error {
+	query := `
+		INSERT INTO price_changes (product_id, new_price, status, effective_at, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, status, created_at
+	`
+
+	return repo.db.QueryRow(query, change.ProductID, change.NewPrice, models.PriceChangeStatusScheduled, change.EffectiveAt, change.CreatedBy).Scan(&change.ID, &change.Status, &change.CreatedAt)
+}
+
+// Cancel withdraws a scheduled change of the product that has not been
+// applied yet.
+func (repo *PriceChangeRepository) Cancel(productId, id int) error {
+	var status string
+	err := repo.db.QueryRow("SELECT status FROM price_changes WHERE id = $1 AND product_id = $2", id, productId).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("price change id %d not found for product id %d", id, productId)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	result, err := repo.db.Exec("UPDATE price_changes SET status = $1 WHERE id = $2 AND status = $3", models.PriceChangeStatusCancelled, id, models.PriceChangeStatusScheduled)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("price change id %d is already %s", id, status)
+	}
+
+	return nil
+}
+
+// ApplyDue applies every scheduled change effective at or before now, in
+// effective order, and returns how many were applied. Rows locked by
+// another instance are skipped so the scheduler can run on several nodes.
+// Changes of archived products are left alone; archiving cancels them.
+func (repo *PriceChangeRepository) ApplyDue(now time.Time) (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT c.id, c.product_id, c.new_price
+		FROM price_changes c
+		JOIN products p ON p.id = c.product_id
+		WHERE c.status = $1 AND c.effective_at <= $2 AND p.archived_at IS NULL
+		ORDER BY c.effective_at ASC, c.id ASC
+		FOR UPDATE OF c SKIP LOCKED
+	`
+
+	rows, err := tx.Query(query, models.PriceChangeStatusScheduled, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var due []models.PriceChange
+	for rows.Next() {
+		var change models.PriceChange
+		if err := rows.Scan(&change.ID, &change.ProductID, &change.NewPrice); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, change)
+	}
+	rows.Close()
+
+	for _, change := range due {
+		var oldPrice int
+		err := tx.QueryRow("SELECT price FROM products WHERE id = $1 FOR UPDATE", change.ProductID).Scan(&oldPrice)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec("UPDATE products SET price = $1 WHERE id = $2", change.NewPrice, change.ProductID)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec("UPDATE price_changes SET status = $1, old_price = $2, applied_at = NOW() WHERE id = $3", models.PriceChangeStatusApplied, oldPrice, change.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(due), nil
+}
+
+// BulkUpdate prices the products selected by req with newPrice. Products
+// whose price changes are updated and written to the price history in one
+// transaction when apply is true, with the products locked; otherwise the
+// changes are only returned. Archived products, products without a price,
+// such as ingredients and open-price items, and outlet price overrides are
+// left out.
+func (repo *PriceChangeRepository) BulkUpdate(req models.BulkPriceRequest, newPrice func(int) (int, error), changedBy string, apply bool) ([]models.BulkPriceChange, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, name, price
+		FROM products
+		WHERE archived_at IS NULL AND price > 0
+			AND ($1 = 0 OR category_id IN ` + categorySubtree("$1") + `)
+			AND ($2 = '' OR name ILIKE '%' || $2 || '%')
+			AND (cardinality($3::int[]) = 0 OR id = ANY($3))
+		ORDER BY name ASC, id ASC
+	`
+	if apply {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := tx.Query(query, req.CategoryID, escapeLike(req.Name), pq.Array(req.ProductIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.BulkPriceChange, 0)
+	for rows.Next() {
+		var change models.BulkPriceChange
+		if err := rows.Scan(&change.ProductID, &change.Name, &change.OldPrice); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	rows.Close()
+
+	for i := range changes {
+		changes[i].NewPrice, err = newPrice(changes[i].OldPrice)
+		if err != nil {
+			return nil, fmt.Errorf("product %s: %w", changes[i].Name, err)
+		}
+	}
+
+	if !apply {
+		return changes, nil
+	}
+
+	for _, change := range changes {
+		if change.NewPrice == change.OldPrice {
+			continue
+		}
+
+		_, err = tx.Exec("UPDATE products SET price = $1 WHERE id = $2", change.NewPrice, change.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := recordPriceChange(tx, change.ProductID, &change.OldPrice, change.NewPrice, changedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// recordPriceChange adds an applied entry to the price history. oldPrice
+// is nil for a new product.
+func recordPriceChange(tx *sql.Tx, productID int, oldPrice *int, newPrice int, changedBy string) error {
+	query := `
+		INSERT INTO price_changes (product_id, old_price, new_price, status, created_by, applied_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NOW())
+	`
+
+	_, err := tx.Exec(query, productID, oldPrice, newPrice, models.PriceChangeStatusApplied, changedBy)
+
+	return err
+}
+
+// escapeLike escapes the LIKE wildcards in s, so a name filter such as
+// "50%" matches the text literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,261 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+	"math"
+)
+
+type PriceListRepository struct {
+	db *sql.DB
+}
+
+func NewPriceListRepository(db *sql.DB) *PriceListRepository {
+	return &PriceListRepository{db: db}
+}
+
+func scanPriceList(row interface{ Scan(...any) error }, list *models.PriceList) error {
+	var validFrom, validUntil sql.NullTime
+
+	err := row.Scan(&list.ID, &list.Name, &validFrom, &validUntil, &list.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if validFrom.Valid {
+		list.ValidFrom = &validFrom.Time
+	}
+
+	if validUntil.Valid {
+		list.ValidUntil = &validUntil.Time
+	}
+
+	return nil
+}
+
+func (repo *PriceListRepository) FindAll() ([]models.PriceList, error) {
+	query := "SELECT id, name, valid_from, valid_until, created_at FROM price_lists ORDER BY name ASC"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]models.PriceList, 0)
+	for rows.Next() {
+		var list models.PriceList
+		if err := scanPriceList(rows, &list); err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+
+	return lists, nil
+}
+
+func (repo *PriceListRepository) FindById(id int) (*models.PriceList, error) {
+	query := "SELECT id, name, valid_from, valid_until, created_at FROM price_lists WHERE id = $1"
+
+	var list models.PriceList
+	err := scanPriceList(repo.db.QueryRow(query, id), &list)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("price list id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	itemQuery := `
+		SELECT id, price_list_id, product_id, category_id, fixed_price, discount_percent
+		FROM price_list_items
+		WHERE price_list_id = $1
+		ORDER BY id ASC
+	`
+
+	rows, err := repo.db.Query(itemQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list.Items = make([]models.PriceListItem, 0)
+	for rows.Next() {
+		var item models.PriceListItem
+		var productID, categoryID, fixedPrice sql.NullInt64
+		var discount sql.NullFloat64
+
+		err := rows.Scan(&item.ID, &item.PriceListID, &productID, &categoryID, &fixedPrice, &discount)
+		if err != nil {
+			return nil, err
+		}
+
+		if productID.Valid {
+			v := int(productID.Int64)
+			item.ProductID = &v
+		}
+
+		if categoryID.Valid {
+			v := int(categoryID.Int64)
+			item.CategoryID = &v
+		}
+
+		if fixedPrice.Valid {
+			v := int(fixedPrice.Int64)
+			item.FixedPrice = &v
+		}
+
+		if discount.Valid {
+			item.DiscountPercent = &discount.Float64
+		}
+
+		list.Items = append(list.Items, item)
+	}
+
+	return &list, nil
+}
+
+func (repo *PriceListRepository) Create(list *models.PriceList) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO price_lists (name, valid_from, valid_until) VALUES ($1, $2, $3) RETURNING id, created_at"
+
+	err = tx.QueryRow(query, list.Name, list.ValidFrom, list.ValidUntil).Scan(&list.ID, &list.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPriceListItems(tx, list); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the name, validity window and rules of the price list.
+func (repo *PriceListRepository) Update(list *models.PriceList) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE price_lists SET name = $1, valid_from = $2, valid_until = $3 WHERE id = $4 RETURNING created_at"
+
+	err = tx.QueryRow(query, list.Name, list.ValidFrom, list.ValidUntil, list.ID).Scan(&list.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("price list not found")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM price_list_items WHERE price_list_id = $1", list.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPriceListItems(tx, list); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PriceListRepository) Delete(id int) error {
+	query := "DELETE FROM price_lists WHERE id = $1"
+
+	result, err := repo.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("price list not found")
+	}
+
+	return nil
+}
+
+// IsUsed reports whether a customer group or a past transaction refers to
+// the price list.
+func (repo *PriceListRepository) IsUsed(id int) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM customer_groups WHERE price_list_id = $1)
+			OR EXISTS (SELECT 1 FROM transaction_details WHERE price_list_id = $1)
+	`
+
+	var used bool
+	err := repo.db.QueryRow(query, id).Scan(&used)
+
+	return used, err
+}
+
+func insertPriceListItems(tx *sql.Tx, list *models.PriceList) error {
+	for i := range list.Items {
+		list.Items[i].PriceListID = list.ID
+		item := list.Items[i]
+		query := `
+			INSERT INTO price_list_items (price_list_id, product_id, category_id, fixed_price, discount_percent)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`
+		err := tx.QueryRow(query, list.ID, item.ProductID, item.CategoryID, item.FixedPrice, item.DiscountPercent).Scan(&list.Items[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findListPrice returns the price of the product on the price list, or
+// false when no rule covers it. The product's own rule wins over a
+// category rule, and the rule of its nearest category up the tree wins
+// over those further up; a discount is taken off basePrice and rounded to
+// the Rupiah.
+func findListPrice(tx *sql.Tx, priceListID, productID, categoryID, basePrice int) (int, bool, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM categories WHERE id = $3
+			UNION ALL
+			SELECT c.id, c.parent_id, a.depth + 1 FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT i.fixed_price, i.discount_percent
+		FROM price_list_items i
+		LEFT JOIN ancestors a ON a.id = i.category_id
+		WHERE i.price_list_id = $1 AND (i.product_id = $2 OR a.id IS NOT NULL)
+		ORDER BY i.product_id IS NULL ASC, a.depth ASC, i.id ASC
+		LIMIT 1
+	`
+
+	var fixedPrice sql.NullInt64
+	var discount sql.NullFloat64
+	err := tx.QueryRow(query, priceListID, productID, categoryID).Scan(&fixedPrice, &discount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	if fixedPrice.Valid {
+		return int(fixedPrice.Int64), true, nil
+	}
+
+	return int(math.Round(float64(basePrice) * (100 - discount.Float64) / 100)), true, nil
+}
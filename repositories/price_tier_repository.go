@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-go/models"
+)
+
+type PriceTierRepository struct {
+	db *sql.DB
+}
+
+func NewPriceTierRepository(db *sql.DB) *PriceTierRepository {
+	return &PriceTierRepository{db: db}
+}
+
+func (repo *PriceTierRepository) FindByProductId(productId int) ([]models.PriceTier, error) {
+	query := "SELECT id, product_id, min_quantity, price FROM product_price_tiers WHERE product_id = $1 ORDER BY min_quantity ASC"
+
+	rows, err := repo.db.Query(query, productId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tiers := make([]models.PriceTier, 0)
+	for rows.Next() {
+		var tier models.PriceTier
+		err := rows.Scan(&tier.ID, &tier.ProductID, &tier.MinQuantity, &tier.Price)
+		if err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, tier)
+	}
+
+	return tiers, nil
+}
+
+// Replace swaps the product's tiers for the given set. An empty set
+// removes wholesale pricing.
+func (repo *PriceTierRepository) Replace(productId int, tiers []models.PriceTier) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM product_price_tiers WHERE product_id = $1", productId)
+	if err != nil {
+		return err
+	}
+
+	for i := range tiers {
+		tiers[i].ProductID = productId
+		query := "INSERT INTO product_price_tiers (product_id, min_quantity, price) VALUES ($1, $2, $3) RETURNING id"
+		err := tx.QueryRow(query, productId, tiers[i].MinQuantity, tiers[i].Price).Scan(&tiers[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// findPriceTier returns the highest tier the quantity reaches, or nil.
+func findPriceTier(tx *sql.Tx, productID, quantity int) (*models.PriceTier, error) {
+	query := `
+		SELECT id, product_id, min_quantity, price
+		FROM product_price_tiers
+		WHERE product_id = $1 AND min_quantity <= $2
+		ORDER BY min_quantity DESC
+		LIMIT 1
+	`
+
+	var tier models.PriceTier
+	err := tx.QueryRow(query, productID, quantity).Scan(&tier.ID, &tier.ProductID, &tier.MinQuantity, &tier.Price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tier, nil
+}
@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+	"strings"
+)
+
+type ProductImportRepository struct {
+	db         *sql.DB
+	costMethod string
+}
+
+func NewProductImportRepository(db *sql.DB, costMethod string) *ProductImportRepository {
+	return &ProductImportRepository{db: db, costMethod: costMethod}
+}
+
+// Import upserts the records by SKU in one transaction. Categories are
+// matched by name, ignoring case, and created when missing. Every record
+// runs under a savepoint so a failing row is reported without hiding the
+// outcome of the others; the transaction is only committed when all rows
+// succeed and apply is true.
+func (repo *ProductImportRepository) Import(records []models.ProductImportRecord, storeId int, createdBy string, apply bool) (*models.ProductImportResult, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkStore(tx, storeId); err != nil {
+		return nil, err
+	}
+
+	categories, created, err := resolveImportCategories(tx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ProductImportResult{
+		CategoriesCreated: created,
+		Rows:              make([]models.ProductImportRow, 0, len(records)),
+	}
+
+	for _, record := range records {
+		row := models.ProductImportRow{Row: record.Row, SKU: record.SKU, Name: record.Name}
+
+		if _, err := tx.Exec("SAVEPOINT import_row"); err != nil {
+			return nil, err
+		}
+
+		row.Action, row.ProductID, row.Warnings, err = importProduct(tx, repo.costMethod, record, categories, storeId, createdBy)
+		if err != nil {
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT import_row"); err != nil {
+				return nil, err
+			}
+			row.Action = models.ProductImportError
+			row.Errors = []string{err.Error()}
+		} else if _, err := tx.Exec("RELEASE SAVEPOINT import_row"); err != nil {
+			return nil, err
+		}
+
+		result.Rows = append(result.Rows, row)
+	}
+
+	for _, row := range result.Rows {
+		if row.Action == models.ProductImportError {
+			return result, nil
+		}
+	}
+
+	if !apply {
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	result.Applied = true
+
+	return result, nil
+}
+
+// resolveImportCategories maps the lower-cased category names used by the
+// records to their ids, creating the missing ones.
+func resolveImportCategories(tx *sql.Tx, records []models.ProductImportRecord) (map[string]int, []string, error) {
+	categories := make(map[string]int)
+
+	rows, err := tx.Query("SELECT id, name FROM categories ORDER BY id ASC")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := categories[key]; !ok {
+			categories[key] = id
+		}
+	}
+	rows.Close()
+
+	created := make([]string, 0)
+	for _, record := range records {
+		key := strings.ToLower(record.Category)
+		if key == "" {
+			continue
+		}
+
+		if _, ok := categories[key]; ok {
+			continue
+		}
+
+		var id int
+		err := tx.QueryRow("INSERT INTO categories (name, description) VALUES ($1, '') RETURNING id", record.Category).Scan(&id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		categories[key] = id
+		created = append(created, record.Category)
+	}
+
+	return categories, created, nil
+}
+
+// importProduct creates the product of the record, or updates the one with
+// the same SKU. A stock value sets the outlet's stock through an
+// adjustment, so the ledger explains the change. The cost of an existing
+// product follows its stock movements, so a different cost is reported as
+// a warning and not imported. Archived products are not updated.
+func importProduct(tx *sql.Tx, costMethod string, record models.ProductImportRecord, categories map[string]int, storeID int, createdBy string) (string, int, []string, error) {
+	categoryID, hasCategory := categories[strings.ToLower(record.Category)]
+
+	var product models.Product
+	err := scanProduct(tx.QueryRow("SELECT "+productColumns+" FROM products WHERE sku = $1 FOR UPDATE", record.SKU), &product)
+	if errors.Is(err, sql.ErrNoRows) {
+		if !hasCategory {
+			return "", 0, nil, fmt.Errorf("category is required for a new product")
+		}
+
+		if record.Price == nil {
+			return "", 0, nil, fmt.Errorf("price is required for a new product")
+		}
+
+		product = models.Product{
+			Name:       record.Name,
+			Price:      *record.Price,
+			CategoryID: categoryID,
+			SKU:        record.SKU,
+		}
+		if record.Cost != nil {
+			product.Cost = *record.Cost
+		}
+		if record.Stock != nil {
+			product.Stock = *record.Stock
+		}
+		if record.Barcode != nil {
+			product.Barcode = *record.Barcode
+		}
+		if record.ReorderPoint != nil {
+			product.ReorderPoint = *record.ReorderPoint
+		}
+		if record.ReorderQuantity != nil {
+			product.ReorderQuantity = *record.ReorderQuantity
+		}
+
+		if err := insertProduct(tx, costMethod, &product, storeID, createdBy); err != nil {
+			return "", 0, nil, err
+		}
+
+		return models.ProductImportCreate, product.ID, nil, nil
+	}
+
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	if product.ArchivedAt != nil {
+		return "", 0, nil, fmt.Errorf("sku %s belongs to archived product %s; restore it before importing", record.SKU, product.Name)
+	}
+
+	var warnings []string
+	if record.Cost != nil && *record.Cost != product.Cost {
+		warnings = append(warnings, fmt.Sprintf("cost ignored for existing product; its cost stays %d", product.Cost))
+	}
+
+	oldPrice := product.Price
+	product.Name = record.Name
+	if hasCategory {
+		product.CategoryID = categoryID
+	}
+	if record.Price != nil {
+		product.Price = *record.Price
+	}
+	if record.Barcode != nil {
+		product.Barcode = *record.Barcode
+	}
+	if record.ReorderPoint != nil {
+		product.ReorderPoint = *record.ReorderPoint
+	}
+	if record.ReorderQuantity != nil {
+		product.ReorderQuantity = *record.ReorderQuantity
+	}
+
+	query := `
+		UPDATE products
+		SET name = $1, price = $2, category_id = $3, barcode = NULLIF($4, ''), reorder_point = $5, reorder_quantity = $6
+		WHERE id = $7
+	`
+
+	_, err = tx.Exec(query, product.Name, product.Price, product.CategoryID, product.Barcode, product.ReorderPoint, product.ReorderQuantity, product.ID)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	if product.Price != oldPrice {
+		if err := recordPriceChange(tx, product.ID, &oldPrice, product.Price, createdBy); err != nil {
+			return "", 0, nil, err
+		}
+	}
+
+	if record.Stock != nil {
+		var stock int
+		err := tx.QueryRow("SELECT COALESCE((SELECT stock FROM store_products WHERE store_id = $1 AND product_id = $2), 0)", storeID, product.ID).Scan(&stock)
+		if err != nil {
+			return "", 0, nil, err
+		}
+
+		if *record.Stock != stock {
+			err = applyStockMovement(tx, costMethod, &models.StockMovement{
+				ProductID:     product.ID,
+				StoreID:       storeID,
+				Type:          models.StockMovementAdjustment,
+				Quantity:      *record.Stock - stock,
+				ReferenceType: "product",
+				ReferenceID:   product.ID,
+				CreatedBy:     createdBy,
+			})
+			if err != nil {
+				return "", 0, nil, err
+			}
+		}
+	}
+
+	return models.ProductImportUpdate, product.ID, warnings, nil
+}
@@ -5,59 +5,353 @@ import (
 	"errors"
 	"fmt"
 	"kasir-go/models"
+	"strings"
 )
 
 type ProductRepository struct {
-	db *sql.DB
+	db         *sql.DB
+	costMethod string
 }
 
-func NewProductRepository(db *sql.DB) *ProductRepository {
-	return &ProductRepository{db: db}
+func NewProductRepository(db *sql.DB, costMethod string) *ProductRepository {
+	return &ProductRepository{db: db, costMethod: costMethod}
 }
 
-func (repo *ProductRepository) FindAll(name string) ([]models.Product, error) {
-	query := "SELECT id, name, price, stock, category_id FROM products"
+const productColumns = "id, name, price, cost, stock, category_id, COALESCE(barcode, ''), COALESCE(sku, ''), reorder_point, reorder_quantity, archived_at, is_bundle, is_ingredient, unit, type, open_price, min_price, max_price"
 
-	var args []interface{}
-	if name != "" {
-		query += " WHERE name ILIKE $1"
-		args = append(args, "%"+name+"%")
+// scanProduct reads productColumns into product, followed by any extra
+// columns the query selects.
+func scanProduct(row interface{ Scan(...any) error }, product *models.Product, extra ...any) error {
+	var archivedAt sql.NullTime
+	var minPrice, maxPrice sql.NullInt64
+
+	dest := []any{&product.ID, &product.Name, &product.Price, &product.Cost, &product.Stock, &product.CategoryID, &product.Barcode,
+		&product.SKU, &product.ReorderPoint, &product.ReorderQuantity, &archivedAt, &product.IsBundle, &product.IsIngredient, &product.Unit,
+		&product.Type, &product.OpenPrice, &minPrice, &maxPrice}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return err
 	}
 
-	query += " ORDER BY created_at DESC"
+	if archivedAt.Valid {
+		product.ArchivedAt = &archivedAt.Time
+	}
 
-	rows, err := repo.db.Query(query, args...)
+	if minPrice.Valid {
+		v := int(minPrice.Int64)
+		product.MinPrice = &v
+	}
+
+	if maxPrice.Valid {
+		v := int(maxPrice.Int64)
+		product.MaxPrice = &v
+	}
+
+	return nil
+}
+
+// catalogProducts exposes products under the columns of the products
+// table with the stock of a bundle worked out from its components, so
+// productColumns can be selected from it.
+const catalogProducts = `(
+	SELECT p.id, p.name, p.price, p.cost,
+		CASE WHEN p.is_bundle THEN (
+			SELECT COALESCE(MIN(GREATEST(c.stock, 0) / bi.quantity), 0)
+			FROM product_bundle_items bi
+			JOIN products c ON c.id = bi.component_id
+			WHERE bi.bundle_id = p.id
+		) ELSE p.stock END AS stock,
+		p.category_id, p.barcode, p.sku, p.reorder_point, p.reorder_quantity, p.archived_at, p.is_bundle, p.is_ingredient, p.unit,
+		p.type, p.open_price, p.min_price, p.max_price, p.created_at
+	FROM products p
+) products`
+
+// storeProducts is catalogProducts with the stock and price of one outlet
+// ($1).
+const storeProducts = `(
+	SELECT p.id, p.name, COALESCE(sp.price, p.price) AS price, p.cost,
+		CASE WHEN p.is_bundle THEN (
+			SELECT COALESCE(MIN(GREATEST(COALESCE(csp.stock, 0), 0) / bi.quantity), 0)
+			FROM product_bundle_items bi
+			LEFT JOIN store_products csp ON csp.product_id = bi.component_id AND csp.store_id = $1
+			WHERE bi.bundle_id = p.id
+		) ELSE COALESCE(sp.stock, 0) END AS stock,
+		p.category_id, p.barcode, p.sku, p.reorder_point, p.reorder_quantity, p.archived_at, p.is_bundle, p.is_ingredient, p.unit,
+		p.type, p.open_price, p.min_price, p.max_price, p.created_at
+	FROM products p
+	LEFT JOIN store_products sp ON sp.product_id = p.id AND sp.store_id = $1
+) products`
+
+// productSource returns the table to select products from: the shared
+// catalogue with total stock, or the catalogue as seen by one outlet.
+func productSource(storeId int) (string, []interface{}) {
+	if storeId == 0 {
+		return catalogProducts, nil
+	}
+
+	return storeProducts, []interface{}{storeId}
+}
+
+// FindAll lists the page of the catalogue that matches the filter, with
+// stock and price of one outlet when filter.StoreID is not zero.
+func (repo *ProductRepository) FindAll(filter models.ProductFilter) ([]models.Product, error) {
+	products := make([]models.Product, 0)
+
+	err := repo.Each(filter, func(product *models.Product) error {
+		products = append(products, *product)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	return products, nil
+}
+
+// Count returns how many products match the filter, ignoring its page.
+func (repo *ProductRepository) Count(filter models.ProductFilter) (int, error) {
+	from, args := productQuery(filter)
+
+	var total int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM "+from, args...).Scan(&total)
+
+	return total, err
+}
+
+// Each calls fn for every product FindAll would list, with its category
+// name, one row at a time so large catalogues are never held in memory.
+// Iteration stops at the first error fn returns.
+func (repo *ProductRepository) Each(filter models.ProductFilter, fn func(*models.Product) error) error {
+	from, args := productQuery(filter)
+	order, args := productOrder(filter, args)
+	query := "SELECT " + productColumns + ", COALESCE((SELECT c.name FROM categories c WHERE c.id = products.category_id), '')" +
+		" FROM " + from + " " + order
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	products := make([]models.Product, 0)
 	for rows.Next() {
 		var product models.Product
-		err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CategoryID)
+		err := scanProduct(rows, &product, &product.CategoryName)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if err := fn(&product); err != nil {
+			return err
 		}
-		products = append(products, product)
 	}
 
-	return products, nil
+	return rows.Err()
+}
+
+// productQuery returns the FROM and WHERE clauses selecting the products
+// that match the filter, with their arguments.
+func productQuery(filter models.ProductFilter) (string, []interface{}) {
+	source, args := productSource(filter.StoreID)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var conditions []string
+	switch filter.Archived {
+	case models.ProductArchivedOnly:
+		conditions = append(conditions, "archived_at IS NOT NULL")
+	case models.ProductArchivedInclude:
+	default:
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+
+	// <% is the trigram word similarity operator; it lets "indomi" find
+	// "Indomie Goreng" and is served by the trigram index
+	if filter.Name != "" {
+		p := arg(filter.Name)
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE '%%' || %s || '%%' OR %s <%% name OR sku = %s OR barcode = %s)", p, p, p, p))
+	}
+
+	if filter.CategoryID != 0 {
+		conditions = append(conditions, "category_id IN "+categorySubtree(arg(filter.CategoryID)))
+	}
+
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= "+arg(*filter.MinPrice))
+	}
+
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= "+arg(*filter.MaxPrice))
+	}
+
+	// products without stock are always available
+	if filter.InStock {
+		conditions = append(conditions, "(stock > 0 OR type <> "+arg(models.ProductTypeStocked)+")")
+	}
+
+	from := source
+	if len(conditions) > 0 {
+		from += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return from, args
+}
+
+// productOrder returns the ORDER BY clause for the filter. Without a sort
+// key a name search ranks prefix matches first, then by similarity;
+// otherwise the newest products come first. Ties fall back to the id so
+// pages do not overlap.
+func productOrder(filter models.ProductFilter, args []interface{}) (string, []interface{}) {
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	switch filter.Sort {
+	case models.ProductSortName:
+		return "ORDER BY name " + direction + ", id " + direction, args
+	case models.ProductSortPrice:
+		return "ORDER BY price " + direction + ", id " + direction, args
+	case models.ProductSortStock:
+		return "ORDER BY stock " + direction + ", id " + direction, args
+	case models.ProductSortBestSelling:
+		storeCondition := ""
+		if filter.StoreID != 0 {
+			storeCondition = " AND t.store_id = $1"
+		}
+
+		sold := `(
+			SELECT COALESCE(SUM(td.quantity), 0)
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE td.product_id = products.id AND t.created_at >= NOW() - INTERVAL '30 days'` + storeCondition + `
+		)`
+
+		return "ORDER BY " + sold + " " + direction + ", id " + direction, args
+	case models.ProductSortCreatedAt:
+		return "ORDER BY created_at " + direction + ", id " + direction, args
+	}
+
+	if filter.Name != "" {
+		args = append(args, filter.Name)
+		p := fmt.Sprintf("$%d", len(args))
+		return fmt.Sprintf("ORDER BY name ILIKE %s || '%%' DESC, word_similarity(%s, name) DESC, name ASC, id ASC", p, p), args
+	}
+
+	return "ORDER BY created_at DESC, id DESC", args
 }
 
-func (repo *ProductRepository) Create(product *models.Product) error {
-	query := "INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id"
+func (repo *ProductRepository) Create(product *models.Product, createdBy string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	err := repo.db.QueryRow(query, product.Name, product.Price, product.Stock, product.CategoryID).Scan(&product.ID)
+	if err := insertProduct(tx, repo.costMethod, product, models.DefaultStoreID, createdBy); err != nil {
+		return err
+	}
 
-	return err
+	return tx.Commit()
+}
+
+// insertProduct adds the product to the catalogue with its first price and
+// components, and books product.Stock as opening stock at the outlet.
+func insertProduct(tx *sql.Tx, costMethod string, product *models.Product, storeID int, createdBy string) error {
+	query := `
+		INSERT INTO products (name, price, cost, stock, category_id, barcode, sku, reorder_point, reorder_quantity, is_bundle, is_ingredient, unit,
+			type, open_price, min_price, max_price)
+		VALUES ($1, $2, $3, 0, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id
+	`
+
+	product.IsBundle = len(product.Components) > 0
+	if product.IsBundle && product.IsIngredient {
+		return fmt.Errorf("an ingredient cannot have components")
+	}
+
+	if product.Unit == "" {
+		product.Unit = models.DefaultUnit
+	}
+
+	if product.Type == "" {
+		product.Type = models.ProductTypeStocked
+	}
+
+	if product.Type != models.ProductTypeStocked && (product.IsBundle || product.IsIngredient) {
+		return fmt.Errorf("bundles and ingredients must be stocked products")
+	}
+
+	err := tx.QueryRow(query, product.Name, product.Price, product.Cost, product.CategoryID, product.Barcode, product.SKU,
+		product.ReorderPoint, product.ReorderQuantity, product.IsBundle, product.IsIngredient, product.Unit,
+		product.Type, product.OpenPrice, product.MinPrice, product.MaxPrice).Scan(&product.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertBundleComponents(tx, product.ID, product.Components); err != nil {
+		return err
+	}
+
+	if err := recordPriceChange(tx, product.ID, nil, product.Price, createdBy); err != nil {
+		return err
+	}
+
+	if product.Stock != 0 {
+		err = applyStockMovement(tx, costMethod, &models.StockMovement{
+			ProductID:     product.ID,
+			StoreID:       storeID,
+			Type:          models.StockMovementAdjustment,
+			Quantity:      product.Stock,
+			ReferenceType: "product",
+			ReferenceID:   product.ID,
+			CreatedBy:     createdBy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (repo *ProductRepository) FindById(id int) (*models.Product, error) {
-	query := "SELECT id, name, price, stock, category_id FROM products WHERE id = $1"
+	query := "SELECT " + productColumns + " FROM " + catalogProducts + " WHERE id = $1"
+
+	var product models.Product
+	err := scanProduct(repo.db.QueryRow(query, id), &product)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("product id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// FindByIdAtStore returns the product with the stock and price of one
+// outlet.
+func (repo *ProductRepository) FindByIdAtStore(id int, storeId int) (*models.Product, error) {
+	source, args := productSource(storeId)
+	args = append(args, id)
+	query := "SELECT " + productColumns + " FROM " + source + fmt.Sprintf(" WHERE id = $%d", len(args))
 
 	var product models.Product
-	err := repo.db.QueryRow(query, id).Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CategoryID)
+	err := scanProduct(repo.db.QueryRow(query, args...), &product)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("product id %d not found", id)
 	}
@@ -69,10 +363,155 @@ func (repo *ProductRepository) FindById(id int) (*models.Product, error) {
 	return &product, nil
 }
 
-func (repo *ProductRepository) Update(product *models.Product) error {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5"
+func (repo *ProductRepository) FindByBarcode(barcode string) (*models.Product, error) {
+	query := "SELECT " + productColumns + " FROM " + catalogProducts + " WHERE barcode = $1 AND archived_at IS NULL"
+
+	var product models.Product
+	err := scanProduct(repo.db.QueryRow(query, barcode), &product)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("product with barcode %s not found", barcode)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// Update changes the product details. Stock is not editable here; it only
+// moves through the stock ledger, e.g. via stock adjustments. A new price
+// is written to the price history.
+func (repo *ProductRepository) Update(product *models.Product, updatedBy string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldPrice, stock int
+	var oldType string
+	err = tx.QueryRow("SELECT price, is_bundle, type, stock FROM products WHERE id = $1 FOR UPDATE", product.ID).Scan(&oldPrice, &product.IsBundle, &oldType, &stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("product not found")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if product.IsBundle && product.IsIngredient {
+		return fmt.Errorf("product id %d has components and cannot be an ingredient", product.ID)
+	}
+
+	if product.Unit == "" {
+		product.Unit = models.DefaultUnit
+	}
+
+	if product.Type == "" {
+		product.Type = models.ProductTypeStocked
+	}
+
+	if product.Type != models.ProductTypeStocked {
+		if product.IsBundle || product.IsIngredient {
+			return fmt.Errorf("bundles and ingredients must be stocked products")
+		}
+
+		if oldType == models.ProductTypeStocked && stock != 0 {
+			return fmt.Errorf("product id %d still has %d in stock; adjust it to 0 before making it a %s product", product.ID, stock, product.Type)
+		}
+
+		// a product used by bundles must keep its stock
+		var isComponent bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_bundle_items WHERE component_id = $1)", product.ID).Scan(&isComponent)
+		if err != nil {
+			return err
+		}
+
+		if isComponent {
+			return fmt.Errorf("product id %d is a bundle component and must be stocked", product.ID)
+		}
+	}
+
+	query := `
+		UPDATE products
+		SET name = $1, price = $2, category_id = $3, barcode = NULLIF($4, ''), sku = NULLIF($5, ''), reorder_point = $6, reorder_quantity = $7,
+			is_ingredient = $8, unit = $9, type = $10, open_price = $11, min_price = $12, max_price = $13
+		WHERE id = $14
+		RETURNING stock, cost
+	`
+
+	err = tx.QueryRow(query, product.Name, product.Price, product.CategoryID, product.Barcode, product.SKU, product.ReorderPoint, product.ReorderQuantity,
+		product.IsIngredient, product.Unit, product.Type, product.OpenPrice, product.MinPrice, product.MaxPrice, product.ID).Scan(&product.Stock, &product.Cost)
+	if err != nil {
+		return err
+	}
+
+	if product.Price != oldPrice {
+		if err := recordPriceChange(tx, product.ID, &oldPrice, product.Price, updatedBy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindArchived lists archived products, most recently archived first.
+func (repo *ProductRepository) FindArchived() ([]models.Product, error) {
+	query := "SELECT " + productColumns + " FROM " + catalogProducts + " WHERE archived_at IS NOT NULL ORDER BY archived_at DESC"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+	for rows.Next() {
+		var product models.Product
+		err := scanProduct(rows, &product)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, nil
+}
 
-	result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.CategoryID, product.ID)
+// Archive hides the product from the catalogue and checkout. Its rows are
+// kept so transactions, movements and reports still resolve it. A
+// component of an active bundle cannot be archived until it is taken out
+// of the bundle. Scheduled price changes are cancelled so a restored
+// product keeps the price it was archived with.
+func (repo *ProductRepository) Archive(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var bundleName string
+	bundleQuery := `
+		SELECT b.name
+		FROM product_bundle_items bi
+		JOIN products b ON b.id = bi.bundle_id
+		WHERE bi.component_id = $1 AND b.archived_at IS NULL
+		ORDER BY b.name ASC
+		LIMIT 1
+	`
+	err = tx.QueryRow(bundleQuery, id).Scan(&bundleName)
+	if err == nil {
+		return fmt.Errorf("product id %d is a component of bundle %s; remove it from the bundle before archiving", id, bundleName)
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	query := "UPDATE products SET archived_at = NOW() WHERE id = $1 AND archived_at IS NULL"
+
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		return err
 	}
@@ -83,14 +522,19 @@ func (repo *ProductRepository) Update(product *models.Product) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("product not found")
+		return fmt.Errorf("product not found or already archived")
 	}
 
-	return nil
+	_, err = tx.Exec("UPDATE price_changes SET status = $1 WHERE product_id = $2 AND status = $3", models.PriceChangeStatusCancelled, id, models.PriceChangeStatusScheduled)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (repo *ProductRepository) Delete(id int) error {
-	query := "DELETE FROM products WHERE id = $1"
+func (repo *ProductRepository) Restore(id int) error {
+	query := "UPDATE products SET archived_at = NULL WHERE id = $1 AND archived_at IS NOT NULL"
 
 	result, err := repo.db.Exec(query, id)
 	if err != nil {
@@ -103,14 +547,14 @@ func (repo *ProductRepository) Delete(id int) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("product not found")
+		return fmt.Errorf("product not found or not archived")
 	}
 
 	return nil
 }
 
 func (repo *ProductRepository) FindByCategoryId(categoryId int) ([]models.Product, error) {
-	query := "SELECT id, name, price, stock, category_id FROM products WHERE category_id = $1"
+	query := "SELECT " + productColumns + " FROM products WHERE category_id = $1"
 
 	rows, err := repo.db.Query(query, categoryId)
 	if err != nil {
@@ -121,7 +565,32 @@ func (repo *ProductRepository) FindByCategoryId(categoryId int) ([]models.Produc
 	products := make([]models.Product, 0)
 	for rows.Next() {
 		var product models.Product
-		err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CategoryID)
+		err := scanProduct(rows, &product)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, nil
+}
+
+// FindLowStock lists products at or below their reorder point, measured
+// against one outlet's stock when storeId is not zero.
+func (repo *ProductRepository) FindLowStock(storeId int) ([]models.Product, error) {
+	source, args := productSource(storeId)
+	query := "SELECT " + productColumns + " FROM " + source + " WHERE archived_at IS NULL AND type = 'stocked' AND reorder_point > 0 AND stock <= reorder_point ORDER BY stock - reorder_point ASC, name ASC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+	for rows.Next() {
+		var product models.Product
+		err := scanProduct(rows, &product)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,433 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+	"time"
+)
+
+type PurchaseOrderRepository struct {
+	db         *sql.DB
+	costMethod string
+}
+
+func NewPurchaseOrderRepository(db *sql.DB, costMethod string) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db: db, costMethod: costMethod}
+}
+
+const purchaseOrderColumns = `po.id, po.supplier_id, s.name, po.store_id, po.status, po.notes,
+	COALESCE((SELECT SUM(i.quantity * i.unit_cost) FROM purchase_order_items i WHERE i.purchase_order_id = po.id), 0),
+	COALESCE(po.created_by, ''), po.created_at, po.sent_at, po.closed_at`
+
+func scanPurchaseOrder(row interface{ Scan(...any) error }, po *models.PurchaseOrder) error {
+	var sentAt, closedAt sql.NullTime
+
+	err := row.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.StoreID, &po.Status, &po.Notes, &po.TotalCost,
+		&po.CreatedBy, &po.CreatedAt, &sentAt, &closedAt)
+	if err != nil {
+		return err
+	}
+
+	if sentAt.Valid {
+		po.SentAt = &sentAt.Time
+	}
+
+	if closedAt.Valid {
+		po.ClosedAt = &closedAt.Time
+	}
+
+	return nil
+}
+
+func (repo *PurchaseOrderRepository) FindAll(status string, supplierId int) ([]models.PurchaseOrder, error) {
+	query := `
+		SELECT ` + purchaseOrderColumns + `
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		WHERE ($1 = '' OR po.status = $1) AND ($2 = 0 OR po.supplier_id = $2)
+		ORDER BY po.created_at DESC
+	`
+
+	rows, err := repo.db.Query(query, status, supplierId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.PurchaseOrder, 0)
+	for rows.Next() {
+		var po models.PurchaseOrder
+		if err := scanPurchaseOrder(rows, &po); err != nil {
+			return nil, err
+		}
+		orders = append(orders, po)
+	}
+
+	return orders, nil
+}
+
+func (repo *PurchaseOrderRepository) FindById(id int) (*models.PurchaseOrder, error) {
+	query := `
+		SELECT ` + purchaseOrderColumns + `
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		WHERE po.id = $1
+	`
+
+	var po models.PurchaseOrder
+	err := scanPurchaseOrder(repo.db.QueryRow(query, id), &po)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("purchase order id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	itemQuery := `
+		SELECT i.id, i.purchase_order_id, i.product_id, p.name, i.quantity, i.unit_cost, i.received_quantity
+		FROM purchase_order_items i
+		JOIN products p ON p.id = i.product_id
+		WHERE i.purchase_order_id = $1
+		ORDER BY i.id ASC
+	`
+
+	rows, err := repo.db.Query(itemQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	po.Items = make([]models.PurchaseOrderItem, 0)
+	for rows.Next() {
+		var item models.PurchaseOrderItem
+		err := rows.Scan(&item.ID, &item.PurchaseOrderID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitCost, &item.ReceivedQuantity)
+		if err != nil {
+			return nil, err
+		}
+		po.Items = append(po.Items, item)
+	}
+
+	return &po, nil
+}
+
+func (repo *PurchaseOrderRepository) Create(po *models.PurchaseOrder) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkStore(tx, po.StoreID); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO purchase_orders (supplier_id, store_id, notes, created_by) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id, status, created_at"
+
+	err = tx.QueryRow(query, po.SupplierID, po.StoreID, po.Notes, po.CreatedBy).Scan(&po.ID, &po.Status, &po.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPurchaseOrderItems(tx, po); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the supplier, notes and lines of a draft purchase order.
+func (repo *PurchaseOrderRepository) Update(po *models.PurchaseOrder) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPurchaseOrder(tx, po.ID, models.PurchaseOrderStatusDraft); err != nil {
+		return err
+	}
+
+	if err := checkStore(tx, po.StoreID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET supplier_id = $1, store_id = $2, notes = $3 WHERE id = $4", po.SupplierID, po.StoreID, po.Notes, po.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM purchase_order_items WHERE purchase_order_id = $1", po.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertPurchaseOrderItems(tx, po); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PurchaseOrderRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPurchaseOrder(tx, id, models.PurchaseOrderStatusDraft); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM purchase_orders WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PurchaseOrderRepository) Send(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockPurchaseOrder(tx, id, models.PurchaseOrderStatusDraft); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET status = $1, sent_at = NOW() WHERE id = $2", models.PurchaseOrderStatusSent, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PurchaseOrderRepository) Close(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockPurchaseOrder(tx, id, models.PurchaseOrderStatusSent, models.PurchaseOrderStatusPartiallyReceived, models.PurchaseOrderStatusReceived)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET status = $1, closed_at = NOW() WHERE id = $2", models.PurchaseOrderStatusClosed, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Receive books delivered quantities against the order lines. Stock goes
+// up through the stock ledger at the unit cost on the order, which also
+// updates the product cost.
+func (repo *PurchaseOrderRepository) Receive(id int, items []models.ReceiveItem, receivedBy string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = lockPurchaseOrder(tx, id, models.PurchaseOrderStatusSent, models.PurchaseOrderStatusPartiallyReceived)
+	if err != nil {
+		return err
+	}
+
+	var storeID int
+	err = tx.QueryRow("SELECT store_id FROM purchase_orders WHERE id = $1", id).Scan(&storeID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var productID, quantity, unitCost, received int
+		err := tx.QueryRow("SELECT product_id, quantity, unit_cost, received_quantity FROM purchase_order_items WHERE id = $1 AND purchase_order_id = $2 FOR UPDATE", item.ItemID, id).Scan(&productID, &quantity, &unitCost, &received)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("item id %d not found in purchase order id %d", item.ItemID, id)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if item.Quantity <= 0 {
+			return fmt.Errorf("quantity must be greater than 0 for item id %d", item.ItemID)
+		}
+
+		if received+item.Quantity > quantity {
+			return fmt.Errorf("cannot receive %d for item id %d (ordered: %d, already received: %d)", item.Quantity, item.ItemID, quantity, received)
+		}
+
+		_, err = tx.Exec("UPDATE purchase_order_items SET received_quantity = received_quantity + $1 WHERE id = $2", item.Quantity, item.ItemID)
+		if err != nil {
+			return err
+		}
+
+		receiptQuery := `
+			INSERT INTO purchase_receipts (purchase_order_id, purchase_order_item_id, product_id, quantity, unit_cost, received_by)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		`
+		_, err = tx.Exec(receiptQuery, id, item.ItemID, productID, item.Quantity, unitCost, receivedBy)
+		if err != nil {
+			return err
+		}
+
+		err = applyStockMovement(tx, repo.costMethod, &models.StockMovement{
+			ProductID:     productID,
+			StoreID:       storeID,
+			Type:          models.StockMovementPurchaseReceipt,
+			Quantity:      item.Quantity,
+			InboundCost:   item.Quantity * unitCost,
+			ReferenceType: "purchase_order",
+			ReferenceID:   id,
+			CreatedBy:     receivedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		if item.LotNumber != "" {
+			_, err = addToLot(tx, storeID, productID, item.LotNumber, item.ExpiryDate, item.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var outstanding int
+	err = tx.QueryRow("SELECT COALESCE(SUM(quantity - received_quantity), 0) FROM purchase_order_items WHERE purchase_order_id = $1", id).Scan(&outstanding)
+	if err != nil {
+		return err
+	}
+
+	status := models.PurchaseOrderStatusPartiallyReceived
+	if outstanding == 0 {
+		status = models.PurchaseOrderStatusReceived
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *PurchaseOrderRepository) FindOpen(storeId int) ([]models.OpenPurchaseOrder, error) {
+	query := `
+		SELECT
+			po.id,
+			po.supplier_id,
+			s.name,
+			po.store_id,
+			po.status,
+			po.created_at,
+			COALESCE(SUM(i.quantity - i.received_quantity), 0) AS outstanding_quantity,
+			COALESCE(SUM((i.quantity - i.received_quantity) * i.unit_cost), 0) AS outstanding_cost
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		LEFT JOIN purchase_order_items i ON i.purchase_order_id = po.id
+		WHERE po.status IN ($1, $2) AND ($3 = 0 OR po.store_id = $3)
+		GROUP BY po.id, s.name
+		ORDER BY po.created_at ASC
+	`
+
+	rows, err := repo.db.Query(query, models.PurchaseOrderStatusSent, models.PurchaseOrderStatusPartiallyReceived, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.OpenPurchaseOrder, 0)
+	for rows.Next() {
+		var po models.OpenPurchaseOrder
+		err := rows.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.StoreID, &po.Status, &po.CreatedAt, &po.OutstandingQuantity, &po.OutstandingCost)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, po)
+	}
+
+	return orders, nil
+}
+
+func (repo *PurchaseOrderRepository) GetSupplierSpendByPeriod(start, end time.Time, storeId int) ([]models.SupplierSpend, error) {
+	query := `
+		SELECT
+			s.id,
+			s.name,
+			COUNT(DISTINCT r.purchase_order_id) AS purchase_count,
+			SUM(r.quantity) AS total_quantity,
+			SUM(r.quantity * r.unit_cost) AS total_spend
+		FROM purchase_receipts r
+		JOIN purchase_orders po ON po.id = r.purchase_order_id
+		JOIN suppliers s ON s.id = po.supplier_id
+		WHERE r.received_at >= $1 AND r.received_at < $2 AND ($3 = 0 OR po.store_id = $3)
+		GROUP BY s.id, s.name
+		ORDER BY total_spend DESC
+	`
+
+	rows, err := repo.db.Query(query, start, end, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spend := make([]models.SupplierSpend, 0)
+	for rows.Next() {
+		var row models.SupplierSpend
+		err := rows.Scan(&row.SupplierID, &row.SupplierName, &row.PurchaseCount, &row.TotalQuantity, &row.TotalSpend)
+		if err != nil {
+			return nil, err
+		}
+		spend = append(spend, row)
+	}
+
+	return spend, nil
+}
+
+func insertPurchaseOrderItems(tx *sql.Tx, po *models.PurchaseOrder) error {
+	for i := range po.Items {
+		po.Items[i].PurchaseOrderID = po.ID
+		query := "INSERT INTO purchase_order_items (purchase_order_id, product_id, quantity, unit_cost) VALUES ($1, $2, $3, $4) RETURNING id"
+		err := tx.QueryRow(query, po.ID, po.Items[i].ProductID, po.Items[i].Quantity, po.Items[i].UnitCost).Scan(&po.Items[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lockPurchaseOrder locks the order row and checks it is in one of the
+// allowed statuses.
+func lockPurchaseOrder(tx *sql.Tx, id int, allowed ...string) (string, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("purchase order id %d not found", id)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, s := range allowed {
+		if status == s {
+			return status, nil
+		}
+	}
+
+	return "", fmt.Errorf("purchase order id %d is %s", id, status)
+}
@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+)
+
+type StockAdjustmentRepository struct {
+	db         *sql.DB
+	costMethod string
+}
+
+func NewStockAdjustmentRepository(db *sql.DB, costMethod string) *StockAdjustmentRepository {
+	return &StockAdjustmentRepository{db: db, costMethod: costMethod}
+}
+
+const stockAdjustmentColumns = `id, product_id, store_id, quantity, new_stock, reason, notes, value, status,
+	COALESCE(created_by, ''), COALESCE(reviewed_by, ''), created_at, reviewed_at`
+
+func scanStockAdjustment(row interface{ Scan(...any) error }, adj *models.StockAdjustment) error {
+	var newStock sql.NullInt64
+	var reviewedAt sql.NullTime
+
+	err := row.Scan(&adj.ID, &adj.ProductID, &adj.StoreID, &adj.Quantity, &newStock, &adj.Reason, &adj.Notes, &adj.Value, &adj.Status,
+		&adj.CreatedBy, &adj.ReviewedBy, &adj.CreatedAt, &reviewedAt)
+	if err != nil {
+		return err
+	}
+
+	if newStock.Valid {
+		v := int(newStock.Int64)
+		adj.NewStock = &v
+	}
+
+	if reviewedAt.Valid {
+		adj.ReviewedAt = &reviewedAt.Time
+	}
+
+	return nil
+}
+
+func (repo *StockAdjustmentRepository) FindAll(status string) ([]models.StockAdjustment, error) {
+	query := "SELECT " + stockAdjustmentColumns + " FROM stock_adjustments"
+
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status)
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := make([]models.StockAdjustment, 0)
+	for rows.Next() {
+		var adj models.StockAdjustment
+		if err := scanStockAdjustment(rows, &adj); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, adj)
+	}
+
+	return adjustments, nil
+}
+
+func (repo *StockAdjustmentRepository) FindById(id int) (*models.StockAdjustment, error) {
+	query := "SELECT " + stockAdjustmentColumns + " FROM stock_adjustments WHERE id = $1"
+
+	var adj models.StockAdjustment
+	err := scanStockAdjustment(repo.db.QueryRow(query, id), &adj)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("stock adjustment id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &adj, nil
+}
+
+// Create stores the adjustment and, unless it is waiting for approval,
+// posts it to the stock ledger in the same transaction.
+func (repo *StockAdjustmentRepository) Create(adj *models.StockAdjustment) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkStore(tx, adj.StoreID); err != nil {
+		return err
+	}
+
+	if err := checkAdjustmentReason(adj.Reason, adj.Quantity); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO stock_adjustments (product_id, store_id, quantity, new_stock, reason, notes, value, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(query, adj.ProductID, adj.StoreID, adj.Quantity, adj.NewStock, adj.Reason, adj.Notes, adj.Value, adj.Status, adj.CreatedBy).Scan(&adj.ID, &adj.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if adj.Status == models.AdjustmentStatusApplied {
+		if err := postStockAdjustment(tx, repo.costMethod, adj, adj.CreatedBy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *StockAdjustmentRepository) Approve(id int, reviewedBy string) (*models.StockAdjustment, error) {
+	return repo.review(id, models.AdjustmentStatusApplied, reviewedBy)
+}
+
+func (repo *StockAdjustmentRepository) Reject(id int, reviewedBy string) (*models.StockAdjustment, error) {
+	return repo.review(id, models.AdjustmentStatusRejected, reviewedBy)
+}
+
+func (repo *StockAdjustmentRepository) review(id int, status string, reviewedBy string) (*models.StockAdjustment, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var adj models.StockAdjustment
+	err = scanStockAdjustment(tx.QueryRow("SELECT "+stockAdjustmentColumns+" FROM stock_adjustments WHERE id = $1 FOR UPDATE", id), &adj)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("stock adjustment id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if adj.Status != models.AdjustmentStatusPending {
+		return nil, fmt.Errorf("stock adjustment id %d is already %s", id, adj.Status)
+	}
+
+	if status == models.AdjustmentStatusApplied {
+		// the approval gate only means something if someone else signs off
+		if reviewedBy == "" {
+			return nil, fmt.Errorf("approving stock adjustment id %d needs the reviewer's name", id)
+		}
+
+		if reviewedBy == adj.CreatedBy {
+			return nil, fmt.Errorf("stock adjustment id %d cannot be approved by its creator", id)
+		}
+
+		if err := postStockAdjustment(tx, repo.costMethod, &adj, reviewedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		UPDATE stock_adjustments
+		SET status = $1, quantity = $2, value = $3, reviewed_by = NULLIF($4, ''), reviewed_at = NOW()
+		WHERE id = $5
+		RETURNING reviewed_at
+	`
+
+	var reviewedAt sql.NullTime
+	err = tx.QueryRow(query, status, adj.Quantity, adj.Value, reviewedBy, id).Scan(&reviewedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	adj.Status = status
+	adj.ReviewedBy = reviewedBy
+	adj.ReviewedAt = &reviewedAt.Time
+
+	return &adj, nil
+}
+
+// postStockAdjustment writes the adjustment to the ledger. Absolute counts
+// are resolved against the stock at posting time, so sales made while an
+// adjustment waited for approval are not lost, and the reason is checked
+// again for the resolved quantity. The stored value becomes the ledger cost
+// of the units moved. Only the stock at the adjustment's outlet is
+// considered.
+func postStockAdjustment(tx *sql.Tx, costMethod string, adj *models.StockAdjustment, createdBy string) error {
+	query := `
+		SELECT COALESCE(sp.stock, 0)
+		FROM products p
+		LEFT JOIN store_products sp ON sp.product_id = p.id AND sp.store_id = $2
+		WHERE p.id = $1
+		FOR UPDATE OF p
+	`
+
+	var stock int
+	err := tx.QueryRow(query, adj.ProductID, adj.StoreID).Scan(&stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("product id %d not found", adj.ProductID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if adj.NewStock != nil {
+		adj.Quantity = *adj.NewStock - stock
+		if err := checkAdjustmentReason(adj.Reason, adj.Quantity); err != nil {
+			return fmt.Errorf("stock of product id %d changed since the count: %w", adj.ProductID, err)
+		}
+	}
+
+	if stock+adj.Quantity < 0 {
+		return fmt.Errorf("adjustment would make stock negative for product id %d (available: %d, adjustment: %d)", adj.ProductID, stock, adj.Quantity)
+	}
+
+	adj.Value = 0
+	if adj.Quantity != 0 {
+		movement := &models.StockMovement{
+			ProductID:     adj.ProductID,
+			StoreID:       adj.StoreID,
+			Type:          models.StockMovementAdjustment,
+			Quantity:      adj.Quantity,
+			ReferenceType: "stock_adjustment",
+			ReferenceID:   adj.ID,
+			CreatedBy:     createdBy,
+		}
+		if err := applyStockMovement(tx, costMethod, movement); err != nil {
+			return err
+		}
+
+		adj.Value = movement.Cost
+	}
+
+	_, err = tx.Exec("UPDATE stock_adjustments SET quantity = $1, value = $2 WHERE id = $3", adj.Quantity, adj.Value, adj.ID)
+	return err
+}
+
+// checkAdjustmentReason makes sure the quantity goes the way the reason
+// allows: losses only decrease stock and finds only increase it.
+func checkAdjustmentReason(reason string, quantity int) error {
+	switch reason {
+	case models.AdjustmentReasonDamaged, models.AdjustmentReasonExpired, models.AdjustmentReasonLost:
+		if quantity > 0 {
+			return fmt.Errorf("reason %s can only decrease stock", reason)
+		}
+	case models.AdjustmentReasonFound:
+		if quantity < 0 {
+			return fmt.Errorf("reason %s can only increase stock", reason)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,389 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+)
+
+type StockCountRepository struct {
+	db         *sql.DB
+	costMethod string
+}
+
+func NewStockCountRepository(db *sql.DB, costMethod string) *StockCountRepository {
+	return &StockCountRepository{db: db, costMethod: costMethod}
+}
+
+const stockCountColumns = `c.id, c.store_id, c.category_id, c.status, c.notes,
+	(SELECT COUNT(*) FROM stock_count_items i WHERE i.count_id = c.id),
+	COALESCE(c.created_by, ''), COALESCE(c.finalized_by, ''), c.started_at, c.finalized_at`
+
+func scanStockCount(row interface{ Scan(...any) error }, count *models.StockCount) error {
+	var categoryID sql.NullInt64
+	var finalizedAt sql.NullTime
+
+	err := row.Scan(&count.ID, &count.StoreID, &categoryID, &count.Status, &count.Notes, &count.ItemCount,
+		&count.CreatedBy, &count.FinalizedBy, &count.StartedAt, &finalizedAt)
+	if err != nil {
+		return err
+	}
+
+	if categoryID.Valid {
+		v := int(categoryID.Int64)
+		count.CategoryID = &v
+	}
+
+	if finalizedAt.Valid {
+		count.FinalizedAt = &finalizedAt.Time
+	}
+
+	return nil
+}
+
+func (repo *StockCountRepository) FindAll() ([]models.StockCount, error) {
+	query := "SELECT " + stockCountColumns + " FROM stock_counts c ORDER BY c.started_at DESC"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]models.StockCount, 0)
+	for rows.Next() {
+		var count models.StockCount
+		if err := scanStockCount(rows, &count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+func (repo *StockCountRepository) FindById(id int) (*models.StockCount, error) {
+	query := "SELECT " + stockCountColumns + " FROM stock_counts c WHERE c.id = $1"
+
+	var count models.StockCount
+	err := scanStockCount(repo.db.QueryRow(query, id), &count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("stock count id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &count, nil
+}
+
+// Create opens a count session and snapshots the current system stock of
+// every product in scope at the count's outlet.
+func (repo *StockCountRepository) Create(count *models.StockCount) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkStore(tx, count.StoreID); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO stock_counts (store_id, category_id, notes, created_by) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id, status, started_at"
+
+	err = tx.QueryRow(query, count.StoreID, count.CategoryID, count.Notes, count.CreatedBy).Scan(&count.ID, &count.Status, &count.StartedAt)
+	if err != nil {
+		return err
+	}
+
+	snapshot := `
+		INSERT INTO stock_count_items (count_id, product_id, system_stock)
+		SELECT $1, p.id, COALESCE(sp.stock, 0)
+		FROM products p
+		LEFT JOIN store_products sp ON sp.product_id = p.id AND sp.store_id = $3
+		WHERE ($2::int IS NULL OR p.category_id IN ` + categorySubtree("$2") + `) AND NOT p.is_bundle AND p.type = 'stocked'
+	`
+
+	result, err := tx.Exec(snapshot, count.ID, count.CategoryID, count.StoreID)
+	if err != nil {
+		return err
+	}
+
+	items, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if items == 0 {
+		return fmt.Errorf("no products to count")
+	}
+
+	count.ItemCount = int(items)
+
+	return tx.Commit()
+}
+
+// AddEntry records a count entry. The count row is share-locked while the
+// entry goes in, so an entry either lands before Finalize computes the
+// variances or is refused once the count is closed.
+func (repo *StockCountRepository) AddEntry(entry *models.StockCountEntry) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM stock_counts WHERE id = $1 FOR SHARE", entry.CountID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("stock count id %d not found", entry.CountID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if status != models.StockCountStatusOpen {
+		return fmt.Errorf("stock count id %d is already %s", entry.CountID, status)
+	}
+
+	query := `
+		INSERT INTO stock_count_entries (count_id, product_id, quantity, counted_by)
+		SELECT count_id, product_id, $3, NULLIF($4, '')
+		FROM stock_count_items
+		WHERE count_id = $1 AND product_id = $2
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(query, entry.CountID, entry.ProductID, entry.Quantity, entry.CountedBy).Scan(&entry.ID, &entry.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("product id %d is not part of stock count id %d", entry.ProductID, entry.CountID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *StockCountRepository) FindVariance(countId int) ([]models.StockCountVariance, error) {
+	return findStockCountVariance(repo.db, countId)
+}
+
+// FindIngredientVariance compares the theoretical and actual usage of the
+// ingredients counted in the count since the outlet's previous finalized
+// count of each. Ingredients never counted before are left out, as their
+// opening stock is unknown.
+func (repo *StockCountRepository) FindIngredientVariance(countId int) ([]models.IngredientVariance, error) {
+	query := `
+		WITH closing AS (
+			SELECT product_id, SUM(quantity) AS counted, MAX(created_at) AS counted_at
+			FROM stock_count_entries
+			WHERE count_id = $1
+			GROUP BY product_id
+		),
+		opening AS (
+			SELECT DISTINCT ON (x.product_id) x.product_id, x.count_id, x.counted, x.counted_at
+			FROM (
+				SELECT e.count_id, e.product_id, SUM(e.quantity) AS counted, MAX(e.created_at) AS counted_at
+				FROM stock_count_entries e
+				JOIN stock_counts pc ON pc.id = e.count_id
+				JOIN stock_counts c ON c.id = $1
+				WHERE pc.store_id = c.store_id AND pc.status = $2 AND pc.started_at < c.started_at
+				GROUP BY e.count_id, e.product_id
+			) x
+			ORDER BY x.product_id, x.counted_at DESC
+		)
+		SELECT p.id, p.name, p.unit, o.count_id, o.counted,
+			COALESCE(SUM(m.quantity) FILTER (WHERE m.type IN ($3, $4)), 0),
+			cl.counted,
+			-COALESCE(SUM(m.quantity) FILTER (WHERE m.type = $5), 0),
+			p.cost
+		FROM closing cl
+		JOIN opening o ON o.product_id = cl.product_id
+		JOIN products p ON p.id = cl.product_id
+		JOIN stock_counts c ON c.id = $1
+		LEFT JOIN stock_movements m ON m.product_id = cl.product_id AND m.store_id = c.store_id
+			AND m.created_at > o.counted_at AND m.created_at <= cl.counted_at
+		WHERE p.is_ingredient
+		GROUP BY p.id, p.name, p.unit, o.count_id, o.counted, cl.counted, p.cost
+		ORDER BY p.name ASC
+	`
+
+	rows, err := repo.db.Query(query, countId, models.StockCountStatusFinalized,
+		models.StockMovementPurchaseReceipt, models.StockMovementTransfer, models.StockMovementSale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variances := make([]models.IngredientVariance, 0)
+	for rows.Next() {
+		var v models.IngredientVariance
+		err := rows.Scan(&v.ProductID, &v.ProductName, &v.Unit, &v.OpeningCountID, &v.OpeningStock,
+			&v.Received, &v.ClosingStock, &v.TheoreticalUsage, &v.UnitCost)
+		if err != nil {
+			return nil, err
+		}
+
+		v.ActualUsage = v.OpeningStock + v.Received - v.ClosingStock
+		v.Variance = v.ActualUsage - v.TheoreticalUsage
+		v.ValueImpact = v.Variance * v.UnitCost
+		variances = append(variances, v)
+	}
+
+	return variances, nil
+}
+
+// Finalize posts the variance of every counted product to the stock ledger
+// and closes the session. Products nobody counted are left untouched.
+func (repo *StockCountRepository) Finalize(id int, finalizedBy string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenStockCount(tx, id); err != nil {
+		return err
+	}
+
+	var storeID int
+	err = tx.QueryRow("SELECT store_id FROM stock_counts WHERE id = $1", id).Scan(&storeID)
+	if err != nil {
+		return err
+	}
+
+	variances, err := findStockCountVariance(tx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range variances {
+		if v.CountedStock == nil || v.Variance == 0 {
+			continue
+		}
+
+		err = applyStockMovement(tx, repo.costMethod, &models.StockMovement{
+			ProductID:     v.ProductID,
+			StoreID:       storeID,
+			Type:          models.StockMovementAdjustment,
+			Quantity:      v.Variance,
+			ReferenceType: "stock_count",
+			ReferenceID:   id,
+			CreatedBy:     finalizedBy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stock_counts SET status = $1, finalized_by = NULLIF($2, ''), finalized_at = NOW() WHERE id = $3", models.StockCountStatusFinalized, finalizedBy, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *StockCountRepository) Cancel(id int, cancelledBy string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenStockCount(tx, id); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE stock_counts SET status = $1, finalized_by = NULLIF($2, ''), finalized_at = NOW() WHERE id = $3", models.StockCountStatusCancelled, cancelledBy, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func lockOpenStockCount(tx *sql.Tx, id int) error {
+	var status string
+	err := tx.QueryRow("SELECT status FROM stock_counts WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("stock count id %d not found", id)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if status != models.StockCountStatusOpen {
+		return fmt.Errorf("stock count id %d is already %s", id, status)
+	}
+
+	return nil
+}
+
+func findStockCountVariance(q interface {
+	Query(string, ...any) (*sql.Rows, error)
+}, countId int) ([]models.StockCountVariance, error) {
+	// Movements posted between the snapshot and the last entry for a product
+	// (sales during the count) are part of the expected stock.
+	query := `
+		SELECT
+			i.product_id,
+			p.name,
+			i.system_stock,
+			i.system_stock + COALESCE((
+				SELECT SUM(m.quantity)
+				FROM stock_movements m
+				WHERE m.product_id = i.product_id
+					AND m.store_id = c.store_id
+					AND m.created_at > c.started_at
+					AND m.created_at <= e.last_counted_at
+			), 0) AS expected_stock,
+			e.counted,
+			p.cost
+		FROM stock_count_items i
+		JOIN stock_counts c ON c.id = i.count_id
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN (
+			SELECT product_id, SUM(quantity) AS counted, MAX(created_at) AS last_counted_at
+			FROM stock_count_entries
+			WHERE count_id = $1
+			GROUP BY product_id
+		) e ON e.product_id = i.product_id
+		WHERE i.count_id = $1
+		ORDER BY p.name ASC
+	`
+
+	rows, err := q.Query(query, countId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variances := make([]models.StockCountVariance, 0)
+	for rows.Next() {
+		var v models.StockCountVariance
+		var counted sql.NullInt64
+		err := rows.Scan(&v.ProductID, &v.ProductName, &v.SystemStock, &v.ExpectedStock, &counted, &v.UnitCost)
+		if err != nil {
+			return nil, err
+		}
+
+		if counted.Valid {
+			c := int(counted.Int64)
+			v.CountedStock = &c
+			v.Variance = c - v.ExpectedStock
+			v.ValueImpact = v.Variance * v.UnitCost
+		}
+
+		variances = append(variances, v)
+	}
+
+	return variances, nil
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+	"time"
+)
+
+type StockMovementRepository struct {
+	db *sql.DB
+}
+
+func NewStockMovementRepository(db *sql.DB) *StockMovementRepository {
+	return &StockMovementRepository{db: db}
+}
+
+// FindByProductId lists the product's movements, limited to one outlet
+// when storeId is not zero.
+func (repo *StockMovementRepository) FindByProductId(productId int, storeId int) ([]models.StockMovement, error) {
+	query := `
+		SELECT id, product_id, store_id, type, quantity, balance, cost, COALESCE(reference_type, ''), COALESCE(reference_id, 0), COALESCE(created_by, ''), created_at
+		FROM stock_movements
+		WHERE product_id = $1 AND ($2 = 0 OR store_id = $2)
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := repo.db.Query(query, productId, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]models.StockMovement, 0)
+	for rows.Next() {
+		var m models.StockMovement
+		err := rows.Scan(&m.ID, &m.ProductID, &m.StoreID, &m.Type, &m.Quantity, &m.Balance, &m.Cost, &m.ReferenceType, &m.ReferenceID, &m.CreatedBy, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, nil
+}
+
+// GetStockAsOf rewinds the current stock by every movement recorded after
+// asOf. A zero storeId gives the total across outlets.
+func (repo *StockMovementRepository) GetStockAsOf(productId int, storeId int, asOf time.Time) (int, error) {
+	query := `
+		SELECT
+			CASE WHEN $3 = 0 THEN p.stock
+			ELSE COALESCE((SELECT sp.stock FROM store_products sp WHERE sp.product_id = p.id AND sp.store_id = $3), 0)
+			END
+			- COALESCE((
+				SELECT SUM(m.quantity)
+				FROM stock_movements m
+				WHERE m.product_id = p.id AND m.created_at > $2 AND ($3 = 0 OR m.store_id = $3)
+			), 0)
+		FROM products p
+		WHERE p.id = $1
+	`
+
+	var stock int
+	err := repo.db.QueryRow(query, productId, asOf, storeId).Scan(&stock)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("product id %d not found", productId)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return stock, nil
+}
+
+// applyStockMovement changes the product stock at movement.StoreID by
+// movement.Quantity and writes the ledger entry in the same transaction,
+// filling in the resulting outlet balance and the cost of the units moved.
+// products.stock is kept as the total across outlets.
+//
+// Inbound stock rolls the product's moving average cost and opens a cost
+// layer at the outlet; outbound stock consumes the outlet's oldest layers
+// and trims any lots that no longer fit in the remaining stock. Layers are
+// kept under both cost methods, so switching methods finds them in place.
+// Outbound stock is valued by the layers it consumed under FIFO and at the
+// average cost otherwise.
+func applyStockMovement(tx *sql.Tx, costMethod string, movement *models.StockMovement) error {
+	var stock, cost int
+	var isBundle bool
+	var productType string
+	err := tx.QueryRow("SELECT stock, cost, is_bundle, type FROM products WHERE id = $1 FOR UPDATE", movement.ProductID).Scan(&stock, &cost, &isBundle, &productType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("product id %d not found", movement.ProductID)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if isBundle {
+		return fmt.Errorf("product id %d is a bundle; its stock comes from its components", movement.ProductID)
+	}
+
+	if productType != models.ProductTypeStocked {
+		return fmt.Errorf("product id %d is a %s product and holds no stock", movement.ProductID, productType)
+	}
+
+	newCost := cost
+	if movement.Quantity > 0 {
+		movement.Cost = movement.Quantity * cost
+		switch movement.Type {
+		case models.StockMovementPurchaseReceipt, models.StockMovementTransfer:
+			movement.Cost = movement.InboundCost
+		}
+
+		newCost = movingAverageCost(stock, cost, movement.Quantity, movement.Cost)
+	} else if movement.Quantity < 0 {
+		layerCost, err := consumeCostLayers(tx, movement.StoreID, movement.ProductID, -movement.Quantity, cost)
+		if err != nil {
+			return err
+		}
+
+		movement.Cost = -movement.Quantity * cost
+		if costMethod == models.CostMethodFIFO {
+			movement.Cost = layerCost
+		}
+	}
+
+	_, err = tx.Exec("UPDATE products SET stock = stock + $1, cost = $2 WHERE id = $3", movement.Quantity, newCost, movement.ProductID)
+	if err != nil {
+		return err
+	}
+
+	storeQuery := `
+		INSERT INTO store_products (store_id, product_id, stock)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (store_id, product_id) DO UPDATE SET stock = store_products.stock + EXCLUDED.stock
+		RETURNING stock
+	`
+
+	err = tx.QueryRow(storeQuery, movement.StoreID, movement.ProductID, movement.Quantity).Scan(&movement.Balance)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO stock_movements (product_id, store_id, type, quantity, balance, cost, reference_type, reference_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, 0), NULLIF($9, ''))
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(query, movement.ProductID, movement.StoreID, movement.Type, movement.Quantity, movement.Balance, movement.Cost, movement.ReferenceType, movement.ReferenceID, movement.CreatedBy).Scan(&movement.ID, &movement.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if movement.Quantity < 0 {
+		if err := trimLots(tx, movement.StoreID, movement.ProductID, movement.Balance); err != nil {
+			return err
+		}
+	}
+
+	if movement.Quantity > 0 {
+		if err := openCostLayers(tx, movement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,486 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+	"slices"
+)
+
+type StockTransferRepository struct {
+	db         *sql.DB
+	costMethod string
+}
+
+func NewStockTransferRepository(db *sql.DB, costMethod string) *StockTransferRepository {
+	return &StockTransferRepository{db: db, costMethod: costMethod}
+}
+
+const stockTransferColumns = `id, from_store_id, to_store_id, status, notes,
+	COALESCE(created_by, ''), COALESCE(shipped_by, ''), COALESCE(received_by, ''), created_at, shipped_at, received_at`
+
+func scanStockTransfer(row interface{ Scan(...any) error }, transfer *models.StockTransfer) error {
+	var shippedAt, receivedAt sql.NullTime
+
+	err := row.Scan(&transfer.ID, &transfer.FromStoreID, &transfer.ToStoreID, &transfer.Status, &transfer.Notes,
+		&transfer.CreatedBy, &transfer.ShippedBy, &transfer.ReceivedBy, &transfer.CreatedAt, &shippedAt, &receivedAt)
+	if err != nil {
+		return err
+	}
+
+	if shippedAt.Valid {
+		transfer.ShippedAt = &shippedAt.Time
+	}
+
+	if receivedAt.Valid {
+		transfer.ReceivedAt = &receivedAt.Time
+	}
+
+	return nil
+}
+
+// FindAll lists transfers, optionally by status and by an outlet on either
+// end of the transfer.
+func (repo *StockTransferRepository) FindAll(status string, storeId int) ([]models.StockTransfer, error) {
+	query := `
+		SELECT ` + stockTransferColumns + `
+		FROM stock_transfers
+		WHERE ($1 = '' OR status = $1) AND ($2 = 0 OR from_store_id = $2 OR to_store_id = $2)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := repo.db.Query(query, status, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := make([]models.StockTransfer, 0)
+	for rows.Next() {
+		var transfer models.StockTransfer
+		if err := scanStockTransfer(rows, &transfer); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+func (repo *StockTransferRepository) FindById(id int) (*models.StockTransfer, error) {
+	query := "SELECT " + stockTransferColumns + " FROM stock_transfers WHERE id = $1"
+
+	var transfer models.StockTransfer
+	err := scanStockTransfer(repo.db.QueryRow(query, id), &transfer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("stock transfer id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.Items, err = findStockTransferItems(repo.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transfer, nil
+}
+
+func (repo *StockTransferRepository) Create(transfer *models.StockTransfer) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkTransferStores(tx, transfer); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO stock_transfers (from_store_id, to_store_id, notes, created_by) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id, status, created_at"
+
+	err = tx.QueryRow(query, transfer.FromStoreID, transfer.ToStoreID, transfer.Notes, transfer.CreatedBy).Scan(&transfer.ID, &transfer.Status, &transfer.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertStockTransferItems(tx, transfer); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update replaces the outlets, notes and lines of a draft transfer.
+func (repo *StockTransferRepository) Update(transfer *models.StockTransfer) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockStockTransfer(tx, transfer.ID, models.StockTransferStatusDraft); err != nil {
+		return err
+	}
+
+	if err := checkTransferStores(tx, transfer); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET from_store_id = $1, to_store_id = $2, notes = $3 WHERE id = $4", transfer.FromStoreID, transfer.ToStoreID, transfer.Notes, transfer.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM stock_transfer_items WHERE transfer_id = $1", transfer.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertStockTransferItems(tx, transfer); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *StockTransferRepository) Cancel(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := lockStockTransfer(tx, id, models.StockTransferStatusDraft); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1 WHERE id = $2", models.StockTransferStatusCancelled, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Ship takes the transfer's stock out of the source outlet through the
+// stock ledger and fixes the unit cost the destination will receive it at.
+// Lot-tracked stock is picked soonest expiry first, as at checkout, and
+// the lots are recorded on the line; expired lots only leave when
+// allowExpired is set.
+func (repo *StockTransferRepository) Ship(id int, allowExpired bool, shippedBy string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	transfer, err := lockStockTransfer(tx, id, models.StockTransferStatusDraft)
+	if err != nil {
+		return err
+	}
+
+	items, err := findStockTransferItems(tx, id)
+	if err != nil {
+		return err
+	}
+
+	requested := make(map[int]int)
+	for _, item := range items {
+		query := `
+			SELECT COALESCE(sp.stock, 0)
+			FROM products p
+			LEFT JOIN store_products sp ON sp.product_id = p.id AND sp.store_id = $2
+			WHERE p.id = $1
+			FOR UPDATE OF p
+		`
+
+		var stock int
+		err := tx.QueryRow(query, item.ProductID, transfer.FromStoreID).Scan(&stock)
+		if err != nil {
+			return err
+		}
+
+		requested[item.ProductID] += item.Quantity
+		if stock < requested[item.ProductID] {
+			return fmt.Errorf("insufficient stock for product %s at store id %d (available: %d, requested: %d)", item.ProductName, transfer.FromStoreID, stock, requested[item.ProductID])
+		}
+
+		// lots are picked before the stock goes down so the movement does
+		// not trim them out of expiry order
+		lots, err := pickLots(tx, transfer.FromStoreID, item.ProductID, item.ProductName, item.Quantity, allowExpired)
+		if err != nil {
+			return err
+		}
+
+		for _, lot := range lots {
+			_, err = tx.Exec("INSERT INTO stock_transfer_item_lots (transfer_item_id, lot_id, quantity) VALUES ($1, $2, $3)", item.ID, lot.LotID, lot.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		movement := &models.StockMovement{
+			ProductID:     item.ProductID,
+			StoreID:       transfer.FromStoreID,
+			Type:          models.StockMovementTransfer,
+			Quantity:      -item.Quantity,
+			ReferenceType: "stock_transfer",
+			ReferenceID:   id,
+			CreatedBy:     shippedBy,
+		}
+		if err := applyStockMovement(tx, repo.costMethod, movement); err != nil {
+			return err
+		}
+
+		// the line keeps its exact shipped cost; the unit cost is rounded
+		// for display only
+		unitCost := (movement.Cost + item.Quantity/2) / item.Quantity
+		_, err = tx.Exec("UPDATE stock_transfer_items SET unit_cost = $1, shipped_cost = $2 WHERE id = $3", unitCost, movement.Cost, item.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, shipped_by = NULLIF($2, ''), shipped_at = NOW() WHERE id = $3", models.StockTransferStatusShipped, shippedBy, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Receive adds the delivered quantities to the destination outlet at the
+// shipped unit cost, back into the lots they were shipped from. Shortages
+// and overages stay on the lines as discrepancies; units that never arrived
+// are not returned to the source.
+func (repo *StockTransferRepository) Receive(id int, received []models.TransferReceiveItem, receivedBy string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	transfer, err := lockStockTransfer(tx, id, models.StockTransferStatusShipped)
+	if err != nil {
+		return err
+	}
+
+	items, err := findStockTransferItems(tx, id)
+	if err != nil {
+		return err
+	}
+
+	delivered := make(map[int]models.TransferReceiveItem)
+	for _, r := range received {
+		if !slices.ContainsFunc(items, func(item models.StockTransferItem) bool { return item.ID == r.ItemID }) {
+			return fmt.Errorf("item id %d not found in stock transfer id %d", r.ItemID, id)
+		}
+		delivered[r.ItemID] = r
+	}
+
+	for _, item := range items {
+		r, ok := delivered[item.ID]
+		if !ok {
+			r = models.TransferReceiveItem{ItemID: item.ID, Quantity: item.Quantity}
+		}
+
+		_, err = tx.Exec("UPDATE stock_transfer_items SET received_quantity = $1, notes = $2 WHERE id = $3", r.Quantity, r.Notes, item.ID)
+		if err != nil {
+			return err
+		}
+
+		if r.Quantity == 0 {
+			continue
+		}
+
+		// a full delivery arrives at the exact shipped cost; shortages
+		// and overages at their share of it
+		inboundCost := item.ShippedCost
+		if r.Quantity != item.Quantity {
+			inboundCost = (item.ShippedCost*r.Quantity + item.Quantity/2) / item.Quantity
+		}
+
+		err = applyStockMovement(tx, repo.costMethod, &models.StockMovement{
+			ProductID:     item.ProductID,
+			StoreID:       transfer.ToStoreID,
+			Type:          models.StockMovementTransfer,
+			Quantity:      r.Quantity,
+			InboundCost:   inboundCost,
+			ReferenceType: "stock_transfer",
+			ReferenceID:   id,
+			CreatedBy:     receivedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		// A shortage is taken from the lots expiring last, so no unit is
+		// believed fresher than it is; an overage arrives untracked.
+		remaining := r.Quantity
+		for _, lot := range item.Lots {
+			quantity := min(lot.Quantity, remaining)
+			if quantity == 0 {
+				break
+			}
+
+			if _, err := addToLot(tx, transfer.ToStoreID, item.ProductID, lot.LotNumber, lot.ExpiryDate, quantity); err != nil {
+				return err
+			}
+			remaining -= quantity
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stock_transfers SET status = $1, received_by = NULLIF($2, ''), received_at = NOW() WHERE id = $3", models.StockTransferStatusReceived, receivedBy, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindInTransit lists the lines of shipped transfers that have not been
+// received, optionally for an outlet on either end.
+func (repo *StockTransferRepository) FindInTransit(storeId int) ([]models.InTransitItem, error) {
+	query := `
+		SELECT t.id, t.from_store_id, t.to_store_id, i.product_id, p.name, i.quantity, i.shipped_cost, t.shipped_at
+		FROM stock_transfer_items i
+		JOIN stock_transfers t ON t.id = i.transfer_id
+		JOIN products p ON p.id = i.product_id
+		WHERE t.status = $1 AND ($2 = 0 OR t.from_store_id = $2 OR t.to_store_id = $2)
+		ORDER BY t.shipped_at ASC, i.id ASC
+	`
+
+	rows, err := repo.db.Query(query, models.StockTransferStatusShipped, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.InTransitItem, 0)
+	for rows.Next() {
+		var item models.InTransitItem
+		err := rows.Scan(&item.TransferID, &item.FromStoreID, &item.ToStoreID, &item.ProductID, &item.ProductName, &item.Quantity, &item.CostValue, &item.ShippedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func findStockTransferItems(q interface {
+	Query(string, ...any) (*sql.Rows, error)
+}, transferId int) ([]models.StockTransferItem, error) {
+	query := `
+		SELECT i.id, i.transfer_id, i.product_id, p.name, i.quantity, i.unit_cost, i.shipped_cost, i.received_quantity, i.notes
+		FROM stock_transfer_items i
+		JOIN products p ON p.id = i.product_id
+		WHERE i.transfer_id = $1
+		ORDER BY i.id ASC
+	`
+
+	rows, err := q.Query(query, transferId)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.StockTransferItem, 0)
+	for rows.Next() {
+		var item models.StockTransferItem
+		var received sql.NullInt64
+		err := rows.Scan(&item.ID, &item.TransferID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitCost, &item.ShippedCost, &received, &item.Notes)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if received.Valid {
+			v := int(received.Int64)
+			item.ReceivedQuantity = &v
+			item.Discrepancy = v - item.Quantity
+		}
+
+		items = append(items, item)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lotQuery := `
+		SELECT il.transfer_item_id, l.id, l.lot_number, to_char(l.expiry_date, 'YYYY-MM-DD'), il.quantity
+		FROM stock_transfer_item_lots il
+		JOIN stock_transfer_items i ON i.id = il.transfer_item_id
+		JOIN product_lots l ON l.id = il.lot_id
+		WHERE i.transfer_id = $1
+		ORDER BY l.expiry_date ASC, l.id ASC
+	`
+
+	lotRows, err := q.Query(lotQuery, transferId)
+	if err != nil {
+		return nil, err
+	}
+	defer lotRows.Close()
+
+	for lotRows.Next() {
+		var itemID int
+		var lot models.LotUsage
+		if err := lotRows.Scan(&itemID, &lot.LotID, &lot.LotNumber, &lot.ExpiryDate, &lot.Quantity); err != nil {
+			return nil, err
+		}
+
+		i := slices.IndexFunc(items, func(item models.StockTransferItem) bool { return item.ID == itemID })
+		items[i].Lots = append(items[i].Lots, lot)
+	}
+
+	return items, lotRows.Err()
+}
+
+func insertStockTransferItems(tx *sql.Tx, transfer *models.StockTransfer) error {
+	for i := range transfer.Items {
+		transfer.Items[i].TransferID = transfer.ID
+		query := "INSERT INTO stock_transfer_items (transfer_id, product_id, quantity, notes) VALUES ($1, $2, $3, $4) RETURNING id"
+		err := tx.QueryRow(query, transfer.ID, transfer.Items[i].ProductID, transfer.Items[i].Quantity, transfer.Items[i].Notes).Scan(&transfer.Items[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkTransferStores(tx *sql.Tx, transfer *models.StockTransfer) error {
+	if err := checkStore(tx, transfer.FromStoreID); err != nil {
+		return err
+	}
+
+	return checkStore(tx, transfer.ToStoreID)
+}
+
+// lockStockTransfer locks the transfer row and returns it when its status
+// is one of allowed.
+func lockStockTransfer(tx *sql.Tx, id int, allowed ...string) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	err := scanStockTransfer(tx.QueryRow("SELECT "+stockTransferColumns+" FROM stock_transfers WHERE id = $1 FOR UPDATE", id), &transfer)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("stock transfer id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range allowed {
+		if transfer.Status == s {
+			return &transfer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("stock transfer id %d is %s", id, transfer.Status)
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+)
+
+type StoreRepository struct {
+	db *sql.DB
+}
+
+func NewStoreRepository(db *sql.DB) *StoreRepository {
+	return &StoreRepository{db: db}
+}
+
+func (repo *StoreRepository) FindAll() ([]models.Store, error) {
+	query := "SELECT id, name, address, timezone FROM stores ORDER BY id ASC"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := make([]models.Store, 0)
+	for rows.Next() {
+		var store models.Store
+		err := rows.Scan(&store.ID, &store.Name, &store.Address, &store.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, store)
+	}
+
+	return stores, nil
+}
+
+func (repo *StoreRepository) Create(store *models.Store) error {
+	query := "INSERT INTO stores (name, address, timezone) VALUES ($1, $2, $3) RETURNING id"
+
+	err := repo.db.QueryRow(query, store.Name, store.Address, store.Timezone).Scan(&store.ID)
+
+	return err
+}
+
+func (repo *StoreRepository) FindById(id int) (*models.Store, error) {
+	query := "SELECT id, name, address, timezone FROM stores WHERE id = $1"
+
+	var store models.Store
+	err := repo.db.QueryRow(query, id).Scan(&store.ID, &store.Name, &store.Address, &store.Timezone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("store id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &store, nil
+}
+
+func (repo *StoreRepository) Update(store *models.Store) error {
+	query := "UPDATE stores SET name = $1, address = $2, timezone = $3 WHERE id = $4"
+
+	result, err := repo.db.Exec(query, store.Name, store.Address, store.Timezone, store.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("store not found")
+	}
+
+	return nil
+}
+
+// FindProductStores lists the product's stock and price override at every
+// outlet, including outlets that never stocked it.
+func (repo *StoreRepository) FindProductStores(productId int) ([]models.StoreProduct, error) {
+	query := `
+		SELECT s.id, s.name, COALESCE(sp.stock, 0), sp.price
+		FROM stores s
+		LEFT JOIN store_products sp ON sp.store_id = s.id AND sp.product_id = $1
+		ORDER BY s.id ASC
+	`
+
+	rows, err := repo.db.Query(query, productId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.StoreProduct, 0)
+	for rows.Next() {
+		sp := models.StoreProduct{ProductID: productId}
+		var price sql.NullInt64
+		err := rows.Scan(&sp.StoreID, &sp.StoreName, &sp.Stock, &price)
+		if err != nil {
+			return nil, err
+		}
+
+		if price.Valid {
+			v := int(price.Int64)
+			sp.Price = &v
+		}
+
+		products = append(products, sp)
+	}
+
+	return products, nil
+}
+
+// FindProductStock returns the product's stock at one outlet.
+func (repo *StoreRepository) FindProductStock(storeId, productId int) (int, error) {
+	var stock int
+	err := repo.db.QueryRow("SELECT COALESCE((SELECT stock FROM store_products WHERE store_id = $1 AND product_id = $2), 0)", storeId, productId).Scan(&stock)
+
+	return stock, err
+}
+
+// SetPrice sets or, with a nil price, clears the outlet's price override
+// for a product.
+func (repo *StoreRepository) SetPrice(sp *models.StoreProduct) error {
+	query := `
+		INSERT INTO store_products (store_id, product_id, price)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (store_id, product_id) DO UPDATE SET price = EXCLUDED.price
+		RETURNING stock
+	`
+
+	return repo.db.QueryRow(query, sp.StoreID, sp.ProductID, sp.Price).Scan(&sp.Stock)
+}
+
+// checkStore fails when the outlet does not exist, before it is used to
+// scope stock.
+func checkStore(q interface {
+	QueryRow(string, ...any) *sql.Row
+}, storeID int) error {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM stores WHERE id = $1)", storeID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("store id %d not found", storeID)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-go/models"
+)
+
+type SupplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+func (repo *SupplierRepository) FindAll() ([]models.Supplier, error) {
+	query := "SELECT id, name, phone, email, address FROM suppliers ORDER BY name ASC"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]models.Supplier, 0)
+	for rows.Next() {
+		var supplier models.Supplier
+		err := rows.Scan(&supplier.ID, &supplier.Name, &supplier.Phone, &supplier.Email, &supplier.Address)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+
+	return suppliers, nil
+}
+
+func (repo *SupplierRepository) Create(supplier *models.Supplier) error {
+	query := "INSERT INTO suppliers (name, phone, email, address) VALUES ($1, $2, $3, $4) RETURNING id"
+
+	err := repo.db.QueryRow(query, supplier.Name, supplier.Phone, supplier.Email, supplier.Address).Scan(&supplier.ID)
+
+	return err
+}
+
+func (repo *SupplierRepository) FindById(id int) (*models.Supplier, error) {
+	query := "SELECT id, name, phone, email, address FROM suppliers WHERE id = $1"
+
+	var supplier models.Supplier
+	err := repo.db.QueryRow(query, id).Scan(&supplier.ID, &supplier.Name, &supplier.Phone, &supplier.Email, &supplier.Address)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("supplier id %d not found", id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &supplier, nil
+}
+
+func (repo *SupplierRepository) Update(supplier *models.Supplier) error {
+	query := "UPDATE suppliers SET name = $1, phone = $2, email = $3, address = $4 WHERE id = $5"
+
+	result, err := repo.db.Exec(query, supplier.Name, supplier.Phone, supplier.Email, supplier.Address, supplier.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("supplier not found")
+	}
+
+	return nil
+}
+
+func (repo *SupplierRepository) Delete(id int) error {
+	query := "DELETE FROM suppliers WHERE id = $1"
+
+	result, err := repo.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("supplier not found")
+	}
+
+	return nil
+}
+
+func (repo *SupplierRepository) HasPurchaseOrders(id int) (bool, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM purchase_orders WHERE supplier_id = $1)", id).Scan(&exists)
+
+	return exists, err
+}
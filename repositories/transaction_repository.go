@@ -6,17 +6,20 @@ import (
 	"fmt"
 	"kasir-go/models"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type TransactionRepository struct {
-	db *sql.DB
+	db         *sql.DB
+	costMethod string
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+func NewTransactionRepository(db *sql.DB, costMethod string) *TransactionRepository {
+	return &TransactionRepository{db: db, costMethod: costMethod}
 }
 
-func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+func (repo *TransactionRepository) CreateTransaction(req models.CheckoutRequest, createdBy string) (*models.Transaction, error) {
 	var (
 		res *models.Transaction
 	)
@@ -27,15 +30,61 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 	}
 	defer tx.Rollback()
 
+	if err := checkStore(tx, req.StoreID); err != nil {
+		return nil, err
+	}
+
+	var priceListID *int
+	if req.CustomerID != 0 {
+		priceListID, err = findCustomerPriceList(tx, req.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := lockCheckoutProducts(tx, req.Items); err != nil {
+		return nil, err
+	}
+
 	totalAmount := 0
 
 	details := make([]models.TransactionDetail, 0)
+	requested := make(map[int]int)
 
-	for _, item := range items {
-		var productName string
-		var productID, price, stock int
+	// Stock is checked once all lines are read, since a product can leave
+	// stock both on its own line and as a bundle component.
+	needed := make(map[int]int)
+	available := make(map[int]int)
+	names := make(map[int]string)
+	var stocked []int
+	take := func(productID int, name string, stock, quantity int) {
+		if _, ok := needed[productID]; !ok {
+			stocked = append(stocked, productID)
+		}
+		needed[productID] += quantity
+		available[productID] = stock
+		names[productID] = name
+	}
 
-		err := tx.QueryRow("SELECT id, name, price, stock FROM products WHERE id = $1", item.ProductID).Scan(&productID, &productName, &price, &stock)
+	bundles := make(map[int][]bundleComponent)
+	unstocked := make(map[int]bool)
+
+	for _, item := range req.Items {
+		var productName, productType string
+		var productID, categoryID, price, cost, stock int
+		var archived, isBundle, isIngredient, openPrice bool
+		var minPrice, maxPrice sql.NullInt64
+
+		query := `
+			SELECT p.id, p.name, COALESCE(p.category_id, 0), COALESCE(sp.price, p.price), p.cost, COALESCE(sp.stock, 0),
+				p.archived_at IS NOT NULL, p.is_bundle, p.is_ingredient, p.type, p.open_price, p.min_price, p.max_price
+			FROM products p
+			LEFT JOIN store_products sp ON sp.product_id = p.id AND sp.store_id = $2
+			WHERE p.id = $1
+			FOR UPDATE OF p
+		`
+		err := tx.QueryRow(query, item.ProductID, req.StoreID).Scan(&productID, &productName, &categoryID, &price, &cost, &stock, &archived, &isBundle, &isIngredient,
+			&productType, &openPrice, &minPrice, &maxPrice)
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("product id %d not found", item.ProductID)
 		}
@@ -44,42 +93,198 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 			return nil, err
 		}
 
+		if archived {
+			return nil, fmt.Errorf("product %s is archived and cannot be sold", productName)
+		}
+
+		if isIngredient {
+			return nil, fmt.Errorf("product %s is an ingredient and is only sold through recipes", productName)
+		}
+
 		if item.Quantity <= 0 {
 			return nil, fmt.Errorf("quantity must be greater than 0 for product id %d", item.ProductID)
 		}
 
-		if stock < item.Quantity {
-			return nil, fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)", productName, stock, item.Quantity)
+		requested[productID] += item.Quantity
+
+		if isBundle {
+			if _, ok := bundles[productID]; !ok {
+				bundles[productID], err = lockBundleComponents(tx, req.StoreID, productID)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			for _, component := range bundles[productID] {
+				take(component.ProductID, component.ProductName, component.Stock, component.Quantity*item.Quantity)
+			}
+		} else if productType == models.ProductTypeStocked {
+			take(productID, productName, stock, item.Quantity)
+		} else {
+			unstocked[productID] = true
+		}
+
+		modifiers, modifierPrice, err := pickModifiers(tx, productID, productName, item.ModifierIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		detail := models.TransactionDetail{
+			ProductID:     productID,
+			ProductName:   productName,
+			Quantity:      item.Quantity,
+			UnitPrice:     price,
+			UnitCost:      cost,
+			Modifiers:     modifiers,
+			ModifierPrice: modifierPrice,
 		}
 
-		subtotal := item.Quantity * price
-		totalAmount += subtotal
+		// An entered price is final: price lists and tiers do not apply.
+		if item.Price != nil {
+			if !openPrice {
+				return nil, fmt.Errorf("product %s does not take a price at checkout", productName)
+			}
+
+			if *item.Price < 0 || minPrice.Valid && *item.Price < int(minPrice.Int64) || maxPrice.Valid && *item.Price > int(maxPrice.Int64) {
+				return nil, fmt.Errorf("price %d for product %s is out of bounds", *item.Price, productName)
+			}
+
+			detail.UnitPrice = *item.Price
+			detail.OpenPrice = true
+		} else if openPrice && price <= 0 {
+			return nil, fmt.Errorf("product %s needs a price at checkout", productName)
+		}
+
+		// The customer's price list replaces the regular price; a
+		// percentage is taken off the outlet price.
+		if priceListID != nil && !detail.OpenPrice {
+			listPrice, ok, err := findListPrice(tx, *priceListID, productID, categoryID, price)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				detail.UnitPrice = listPrice
+				detail.PriceListID = priceListID
+			}
+		}
 
-		_, err = tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", item.Quantity, productID)
+		details = append(details, detail)
+	}
+
+	for _, productID := range stocked {
+		if available[productID] < needed[productID] {
+			return nil, fmt.Errorf("insufficient stock for product %s (available: %d, requested: %d)", names[productID], available[productID], needed[productID])
+		}
+	}
+
+	// Tiers match the product's total quantity in the transaction, so
+	// splitting it over several lines does not lose the wholesale price. An
+	// outlet or price list price below the tier price wins.
+	for i := range details {
+		tier, err := findPriceTier(tx, details[i].ProductID, requested[details[i].ProductID])
 		if err != nil {
 			return nil, err
 		}
 
-		details = append(details, models.TransactionDetail{
-			ProductID:   productID,
-			ProductName: productName,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
-		})
+		if tier != nil && tier.Price < details[i].UnitPrice && !details[i].OpenPrice {
+			details[i].UnitPrice = tier.Price
+			details[i].TierMinQuantity = &tier.MinQuantity
+			details[i].PriceListID = nil
+		}
+
+		// modifiers are charged at their own price on top of the unit
+		// price, whatever tier or list priced the product
+		details[i].Subtotal = details[i].Quantity * (details[i].UnitPrice + details[i].ModifierPrice)
+		totalAmount += details[i].Subtotal
 	}
 
+	var customerID *int
+	if req.CustomerID != 0 {
+		customerID = &req.CustomerID
+	}
+
+	// balances before and after the sale, per product, from the ledger
+	levels := make(map[int]*stockLevel)
+
 	var transactionID int
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", totalAmount).Scan(&transactionID)
+	err = tx.QueryRow("INSERT INTO transactions (store_id, customer_id, total_amount) VALUES ($1, $2, $3) RETURNING id", req.StoreID, customerID, totalAmount).Scan(&transactionID)
 	if err != nil {
 		return nil, err
 	}
 
 	for i := range details {
 		details[i].TransactionID = transactionID
-		_, err := tx.Exec("INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4)", details[i].TransactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal)
+
+		// A bundle takes its components' stock; its cost is theirs.
+		if components, ok := bundles[details[i].ProductID]; ok {
+			details[i].COGS = 0
+			for _, component := range components {
+				quantity := component.Quantity * details[i].Quantity
+				cogs, lots, err := repo.takeStock(tx, req, levels, transactionID, component.ProductID, component.ProductName, quantity, component.Cost, createdBy)
+				if err != nil {
+					return nil, err
+				}
+
+				details[i].COGS += cogs
+				details[i].Lots = append(details[i].Lots, lots...)
+				details[i].Components = append(details[i].Components, models.DetailComponent{
+					ProductID:   component.ProductID,
+					ProductName: component.ProductName,
+					Quantity:    quantity,
+					COGS:        cogs,
+				})
+			}
+		} else if unstocked[details[i].ProductID] {
+			// services and non-inventory products cost their standard cost
+			details[i].COGS = details[i].Quantity * details[i].UnitCost
+		} else {
+			details[i].COGS, details[i].Lots, err = repo.takeStock(tx, req, levels, transactionID, details[i].ProductID, details[i].ProductName, details[i].Quantity, details[i].UnitCost, createdBy)
+			if err != nil {
+				return nil, err
+			}
+		}
+		details[i].UnitCost = details[i].COGS / details[i].Quantity
+
+		query := `
+			INSERT INTO transaction_details (transaction_id, product_id, quantity, unit_price, tier_min_quantity, price_list_id, modifier_price, open_price,
+				subtotal, unit_cost, cogs)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id
+		`
+		err = tx.QueryRow(query, details[i].TransactionID, details[i].ProductID, details[i].Quantity, details[i].UnitPrice, details[i].TierMinQuantity,
+			details[i].PriceListID, details[i].ModifierPrice, details[i].OpenPrice, details[i].Subtotal, details[i].UnitCost, details[i].COGS).Scan(&details[i].ID)
 		if err != nil {
 			return nil, err
 		}
+
+		for _, lot := range details[i].Lots {
+			_, err = tx.Exec("INSERT INTO transaction_detail_lots (transaction_detail_id, lot_id, quantity) VALUES ($1, $2, $3)", details[i].ID, lot.LotID, lot.Quantity)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, modifier := range details[i].Modifiers {
+			_, err = tx.Exec("INSERT INTO transaction_detail_modifiers (transaction_detail_id, modifier_id, group_name, name, price) VALUES ($1, $2, $3, $4, $5)",
+				details[i].ID, modifier.ModifierID, modifier.GroupName, modifier.Name, modifier.Price)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, component := range details[i].Components {
+			_, err = tx.Exec("INSERT INTO transaction_detail_components (transaction_detail_id, product_id, quantity, cogs) VALUES ($1, $2, $3, $4)",
+				details[i].ID, component.ProductID, component.Quantity, component.COGS)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	lowStock, err := findReorderCrossings(tx, stocked, levels)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -88,23 +293,141 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 
 	res = &models.Transaction{
 		ID:                 transactionID,
+		StoreID:            req.StoreID,
+		CustomerID:         customerID,
 		TotalAmount:        totalAmount,
 		TransactionDetails: details,
+		LowStock:           lowStock,
 	}
 
 	return res, nil
 }
 
-func (r *TransactionRepository) GetSummaryByPeriod(start, end time.Time) (totalRevenue int, totalTransaction int, err error) {
+// lockCheckoutProducts locks the products of the cart and the components
+// of its bundles in id order, so two carts holding the same products in a
+// different order wait for each other instead of deadlocking.
+func lockCheckoutProducts(tx *sql.Tx, items []models.CheckoutItem) error {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+
+	query := `
+		SELECT id FROM products
+		WHERE id = ANY($1) OR id IN (SELECT component_id FROM product_bundle_items WHERE bundle_id = ANY($1))
+		ORDER BY id ASC
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+
+	return rows.Err()
+}
+
+// stockLevel is a product's outlet stock before and after a transaction.
+type stockLevel struct {
+	before int
+	after  int
+}
+
+// findReorderCrossings returns the products whose stock went from above
+// their reorder point to at or below it, in the order given.
+func findReorderCrossings(tx *sql.Tx, productIDs []int, levels map[int]*stockLevel) ([]models.LowStockAlert, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.Query("SELECT id, name, reorder_point, reorder_quantity FROM products WHERE id = ANY($1) AND reorder_point > 0", pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int]models.LowStockAlert)
+	for rows.Next() {
+		var alert models.LowStockAlert
+		if err := rows.Scan(&alert.ProductID, &alert.ProductName, &alert.ReorderPoint, &alert.ReorderQuantity); err != nil {
+			return nil, err
+		}
+		found[alert.ProductID] = alert
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var alerts []models.LowStockAlert
+	for _, productID := range productIDs {
+		alert, ok := found[productID]
+		level := levels[productID]
+		if !ok || level == nil || level.before <= alert.ReorderPoint || level.after > alert.ReorderPoint {
+			continue
+		}
+
+		alert.Stock = level.after
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+// takeStock picks the lots and posts the sale of quantity units of the
+// product, and returns the cost of goods sold and the lots used. The
+// product's balances are recorded in levels.
+func (repo *TransactionRepository) takeStock(tx *sql.Tx, req models.CheckoutRequest, levels map[int]*stockLevel, transactionID, productID int, productName string, quantity, unitCost int, createdBy string) (int, []models.LotUsage, error) {
+	// lots are picked before the stock goes down so the movement does
+	// not trim them out of expiry order
+	lots, err := pickLots(tx, req.StoreID, productID, productName, quantity, req.AllowExpired)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	movement := &models.StockMovement{
+		ProductID:     productID,
+		StoreID:       req.StoreID,
+		Type:          models.StockMovementSale,
+		Quantity:      -quantity,
+		ReferenceType: "transaction",
+		ReferenceID:   transactionID,
+		CreatedBy:     createdBy,
+	}
+	if err := applyStockMovement(tx, repo.costMethod, movement); err != nil {
+		return 0, nil, err
+	}
+
+	if _, ok := levels[productID]; !ok {
+		levels[productID] = &stockLevel{before: movement.Balance + quantity}
+	}
+	levels[productID].after = movement.Balance
+
+	// The average cost is snapshotted before the sale; FIFO uses the
+	// layers the sale actually consumed.
+	if repo.costMethod == models.CostMethodFIFO {
+		return movement.Cost, lots, nil
+	}
+
+	return quantity * unitCost, lots, nil
+}
+
+// GetSummaryByPeriod totals the transactions of one outlet, or of all
+// outlets when storeId is zero.
+func (r *TransactionRepository) GetSummaryByPeriod(start, end time.Time, storeId int) (totalRevenue int, totalTransaction int, err error) {
 	query := `
 		SELECT
 			COALESCE(SUM(total_amount), 0) AS total_revenue,
 			COUNT(*) AS total_transaction
 		FROM transactions
-		WHERE created_at >= $1 AND created_at < $2
+		WHERE created_at >= $1 AND created_at < $2 AND ($3 = 0 OR store_id = $3)
 	`
 
-	err = r.db.QueryRow(query, start, end).Scan(&totalRevenue, &totalTransaction)
+	err = r.db.QueryRow(query, start, end, storeId).Scan(&totalRevenue, &totalTransaction)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -112,7 +435,7 @@ func (r *TransactionRepository) GetSummaryByPeriod(start, end time.Time) (totalR
 	return totalRevenue, totalTransaction, nil
 }
 
-func (r *TransactionRepository) GetBestSellingProductByPeriod(start, end time.Time) (name string, quantity int, err error) {
+func (r *TransactionRepository) GetBestSellingProductByPeriod(start, end time.Time, storeId int) (name string, quantity int, err error) {
 	query := `
 		SELECT
 			p.name,
@@ -120,13 +443,13 @@ func (r *TransactionRepository) GetBestSellingProductByPeriod(start, end time.Ti
 		FROM transaction_details td
 		JOIN transactions t ON td.transaction_id = t.id
 		JOIN products p ON td.product_id = p.id
-		WHERE t.created_at >= $1 AND t.created_at < $2
-		GROUP BY p.name
-		ORDER BY qty DESC
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+		GROUP BY p.id, p.name
+		ORDER BY qty DESC, p.name ASC, p.id ASC
 		LIMIT 1
 	`
 
-	err = r.db.QueryRow(query, start, end).Scan(&name, &quantity)
+	err = r.db.QueryRow(query, start, end, storeId).Scan(&name, &quantity)
 	if err == sql.ErrNoRows {
 		return "", 0, nil
 	}
@@ -137,3 +460,293 @@ func (r *TransactionRepository) GetBestSellingProductByPeriod(start, end time.Ti
 
 	return name, quantity, nil
 }
+
+// GetProfitByPeriod sums revenue and COGS per product, category or
+// day/week/month bucket in loc, for one outlet or all outlets when storeId
+// is zero. A non-zero categoryId keeps the sales of that category and the
+// categories below it.
+func (r *TransactionRepository) GetProfitByPeriod(start, end time.Time, groupBy string, loc *time.Location, storeId, categoryId int) ([]models.ProfitRow, error) {
+	args := []interface{}{start, end, storeId, categoryId}
+
+	var query string
+	switch groupBy {
+	case "product":
+		query = `
+			SELECT p.id::text, p.id, p.name, SUM(td.subtotal), SUM(td.cogs)
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			JOIN products p ON td.product_id = p.id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+				AND ($4 = 0 OR td.product_id IN (SELECT id FROM products WHERE category_id IN ` + categorySubtree("$4") + `))
+			GROUP BY p.id, p.name
+			ORDER BY SUM(td.subtotal) - SUM(td.cogs) DESC
+		`
+	case "category":
+		query = `
+			SELECT c.id::text, c.id, c.name, SUM(td.subtotal), SUM(td.cogs)
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			JOIN products p ON td.product_id = p.id
+			JOIN categories c ON p.category_id = c.id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+				AND ($4 = 0 OR td.product_id IN (SELECT id FROM products WHERE category_id IN ` + categorySubtree("$4") + `))
+			GROUP BY c.id, c.name
+			ORDER BY SUM(td.subtotal) - SUM(td.cogs) DESC
+		`
+	case "day", "week", "month":
+		query = `
+			SELECT to_char(date_trunc('` + groupBy + `', t.created_at AT TIME ZONE $5), 'YYYY-MM-DD') AS bucket, 0, '', SUM(td.subtotal), SUM(td.cogs)
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+				AND ($4 = 0 OR td.product_id IN (SELECT id FROM products WHERE category_id IN ` + categorySubtree("$4") + `))
+			GROUP BY bucket
+			ORDER BY bucket ASC
+		`
+		args = append(args, loc.String())
+	default:
+		query = `
+			SELECT '', 0, '', COALESCE(SUM(td.subtotal), 0), COALESCE(SUM(td.cogs), 0)
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+				AND ($4 = 0 OR td.product_id IN (SELECT id FROM products WHERE category_id IN ` + categorySubtree("$4") + `))
+		`
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.ProfitRow, 0)
+	for rows.Next() {
+		var row models.ProfitRow
+		err := rows.Scan(&row.Key, &row.ID, &row.Name, &row.Revenue, &row.COGS)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// GetSalesSeriesByPeriod sums revenue, transactions and items sold per
+// hour, day, week or month bucket in loc, for one outlet or all outlets
+// when storeId is zero. Only buckets with sales are returned.
+func (r *TransactionRepository) GetSalesSeriesByPeriod(start, end time.Time, interval string, loc *time.Location, storeId int) ([]models.SalesBucket, error) {
+	query := `
+		SELECT
+			to_char(date_trunc($4, t.created_at AT TIME ZONE $5), 'YYYY-MM-DD HH24:MI:SS') AS bucket,
+			SUM(t.total_amount),
+			COUNT(*),
+			COALESCE(SUM(items.quantity), 0)
+		FROM transactions t
+		LEFT JOIN LATERAL (
+			SELECT SUM(td.quantity) AS quantity FROM transaction_details td WHERE td.transaction_id = t.id
+		) items ON true
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`
+
+	rows, err := r.db.Query(query, start, end, storeId, interval, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.SalesBucket, 0)
+	for rows.Next() {
+		var bucket models.SalesBucket
+		var key string
+		err := rows.Scan(&key, &bucket.Revenue, &bucket.TransactionCount, &bucket.ItemsSold)
+		if err != nil {
+			return nil, err
+		}
+
+		bucket.Start, err = time.ParseInLocation("2006-01-02 15:04:05", key, loc)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, bucket)
+	}
+
+	return result, rows.Err()
+}
+
+// GetSalesRankingByPeriod ranks products or categories by quantity,
+// revenue or profit in the period, best first unless bottom is set, and
+// returns the first limit rows with the metric's total over all of them.
+// Active products rank with zeros when they did not sell, so the bottom
+// shows dead stock; ingredients are left out unless sold directly.
+// Categories are the top level, or the children of categoryId, each with
+// the sales of its whole subtree. For products a non-zero categoryId keeps
+// that category and the categories below it.
+func (r *TransactionRepository) GetSalesRankingByPeriod(start, end time.Time, groupBy, by string, bottom bool, limit, storeId, categoryId int) ([]models.RankingRow, int, error) {
+	column := "quantity"
+	switch by {
+	case models.RankingByRevenue:
+		column = "revenue"
+	case models.RankingByProfit:
+		column = "profit"
+	}
+
+	direction := "DESC"
+	if bottom {
+		direction = "ASC"
+	}
+
+	var query string
+	if groupBy == "category" {
+		metric := "COALESCE(SUM(s." + column + "), 0)"
+		query = `
+			WITH RECURSIVE tree AS (
+				SELECT id AS root_id, id FROM categories WHERE ($5 = 0 AND parent_id IS NULL) OR parent_id = $5
+				UNION ALL
+				SELECT t.root_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+			),
+			sales AS (
+				SELECT p.category_id, SUM(td.quantity) AS quantity, SUM(td.subtotal) AS revenue, SUM(td.subtotal) - SUM(td.cogs) AS profit
+				FROM transaction_details td
+				JOIN transactions t ON td.transaction_id = t.id
+				JOIN products p ON td.product_id = p.id
+				WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+				GROUP BY p.category_id
+			)
+			SELECT c.id, c.name, 0, '',
+				COALESCE(SUM(s.quantity), 0), COALESCE(SUM(s.revenue), 0), COALESCE(SUM(s.profit), 0),
+				SUM(` + metric + `) OVER ()
+			FROM categories c
+			JOIN tree t ON t.root_id = c.id
+			LEFT JOIN sales s ON s.category_id = t.id
+			GROUP BY c.id, c.name
+			ORDER BY ` + metric + ` ` + direction + `, c.name ASC, c.id ASC
+			LIMIT $4
+		`
+	} else {
+		metric := "COALESCE(s." + column + ", 0)"
+		query = `
+			WITH sales AS (
+				SELECT td.product_id, SUM(td.quantity) AS quantity, SUM(td.subtotal) AS revenue, SUM(td.subtotal) - SUM(td.cogs) AS profit
+				FROM transaction_details td
+				JOIN transactions t ON td.transaction_id = t.id
+				WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+				GROUP BY td.product_id
+			)
+			SELECT p.id, p.name, COALESCE(c.id, 0), COALESCE(c.name, ''),
+				COALESCE(s.quantity, 0), COALESCE(s.revenue, 0), COALESCE(s.profit, 0),
+				SUM(` + metric + `) OVER ()
+			FROM products p
+			LEFT JOIN sales s ON s.product_id = p.id
+			LEFT JOIN categories c ON p.category_id = c.id
+			WHERE (s.product_id IS NOT NULL OR (p.archived_at IS NULL AND NOT p.is_ingredient))
+				AND ($5 = 0 OR p.category_id IN ` + categorySubtree("$5") + `)
+			ORDER BY ` + metric + ` ` + direction + `, p.name ASC, p.id ASC
+			LIMIT $4
+		`
+	}
+
+	rows, err := r.db.Query(query, start, end, storeId, limit, categoryId)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	result := make([]models.RankingRow, 0)
+	total := 0
+	for rows.Next() {
+		var row models.RankingRow
+		err := rows.Scan(&row.ID, &row.Name, &row.CategoryID, &row.CategoryName, &row.Quantity, &row.Revenue, &row.Profit, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		row.Rank = len(result) + 1
+		result = append(result, row)
+	}
+
+	return result, total, rows.Err()
+}
+
+// GetComponentUsageByPeriod lists the products that bundles used in the
+// period, with the units sold on their own alongside. Bundle lines are not
+// counted as direct sales; their revenue stays with the bundle. Recipe
+// ingredients are left to GetIngredientUsageByPeriod.
+func (r *TransactionRepository) GetComponentUsageByPeriod(start, end time.Time, storeId int) ([]models.ComponentUsage, error) {
+	query := `
+		WITH usage AS (
+			SELECT td.product_id, td.quantity AS direct, 0 AS bundled, 0 AS cogs
+			FROM transaction_details td
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+				AND NOT EXISTS (SELECT 1 FROM transaction_detail_components tdc WHERE tdc.transaction_detail_id = td.id)
+			UNION ALL
+			SELECT tdc.product_id, 0, tdc.quantity, tdc.cogs
+			FROM transaction_detail_components tdc
+			JOIN transaction_details td ON tdc.transaction_detail_id = td.id
+			JOIN transactions t ON td.transaction_id = t.id
+			WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3)
+		)
+		SELECT p.id, p.name, SUM(u.direct), SUM(u.bundled), SUM(u.cogs)
+		FROM usage u
+		JOIN products p ON u.product_id = p.id
+		WHERE NOT p.is_ingredient
+		GROUP BY p.id, p.name
+		HAVING SUM(u.bundled) > 0
+		ORDER BY SUM(u.bundled) DESC, p.name ASC
+	`
+
+	rows, err := r.db.Query(query, start, end, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.ComponentUsage, 0)
+	for rows.Next() {
+		var row models.ComponentUsage
+		err := rows.Scan(&row.ProductID, &row.ProductName, &row.DirectQuantity, &row.BundleQuantity, &row.BundleCOGS)
+		if err != nil {
+			return nil, err
+		}
+		row.TotalQuantity = row.DirectQuantity + row.BundleQuantity
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// GetIngredientUsageByPeriod totals the ingredients the recipes of the
+// menu items sold in the period used.
+func (r *TransactionRepository) GetIngredientUsageByPeriod(start, end time.Time, storeId int) ([]models.IngredientUsage, error) {
+	query := `
+		SELECT p.id, p.name, p.unit, SUM(tdc.quantity), SUM(tdc.cogs)
+		FROM transaction_detail_components tdc
+		JOIN transaction_details td ON tdc.transaction_detail_id = td.id
+		JOIN transactions t ON td.transaction_id = t.id
+		JOIN products p ON tdc.product_id = p.id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.store_id = $3) AND p.is_ingredient
+		GROUP BY p.id, p.name, p.unit
+		ORDER BY SUM(tdc.cogs) DESC, p.name ASC
+	`
+
+	rows, err := r.db.Query(query, start, end, storeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.IngredientUsage, 0)
+	for rows.Next() {
+		var row models.IngredientUsage
+		if err := rows.Scan(&row.ProductID, &row.ProductName, &row.Unit, &row.Quantity, &row.COGS); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type BundleService struct {
+	repo        *repositories.BundleRepository
+	productRepo *repositories.ProductRepository
+}
+
+func NewBundleService(repo *repositories.BundleRepository, productRepo *repositories.ProductRepository) *BundleService {
+	return &BundleService{repo: repo, productRepo: productRepo}
+}
+
+func (s *BundleService) GetComponents(productId int, storeId int) ([]models.BundleComponent, error) {
+	_, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByProductId(productId, storeId)
+}
+
+// Replace sets the components of the product. An empty list turns a bundle
+// back into a normal product.
+func (s *BundleService) Replace(productId int, components []models.BundleComponent) ([]models.BundleComponent, error) {
+	_, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	for _, component := range components {
+		if component.ProductID == productId {
+			return nil, fmt.Errorf("a bundle cannot contain itself")
+		}
+
+		if seen[component.ProductID] {
+			return nil, fmt.Errorf("duplicate component product id %d", component.ProductID)
+		}
+		seen[component.ProductID] = true
+	}
+
+	if err := s.repo.Replace(productId, components); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByProductId(productId, 0)
+}
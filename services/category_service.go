@@ -15,11 +15,66 @@ func NewCategoryService(categoryRepo *repositories.CategoryRepository, productRe
 	return &CategoryService{categoryRepo: categoryRepo, productRepo: productRepo}
 }
 
-func (s *CategoryService) GetAll() ([]models.Category, error) {
-	return s.categoryRepo.FindAll()
+// GetAll returns a page of categories with their product counts and stock
+// value, and the number of categories over all pages.
+func (s *CategoryService) GetAll(filter models.CategoryFilter) ([]models.CategorySummary, int, error) {
+	categories, err := s.categoryRepo.FindSummaries(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := len(categories)
+	if filter.Limit > 0 || filter.Offset > 0 {
+		total, err = s.categoryRepo.Count()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return categories, total, nil
+}
+
+// GetTree returns the top level categories with their subcategories nested
+// under them, each level sorted by name.
+func (s *CategoryService) GetTree() ([]models.Category, error) {
+	categories, err := s.categoryRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var attach func(nodes []models.Category) []models.Category
+	attach = func(nodes []models.Category) []models.Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	tree := attach(roots)
+	if tree == nil {
+		tree = make([]models.Category, 0)
+	}
+
+	return tree, nil
 }
 
 func (s *CategoryService) Create(data *models.Category) error {
+	if data.ParentID != nil {
+		if _, err := s.categoryRepo.FindById(*data.ParentID); err != nil {
+			return err
+		}
+	}
+
 	return s.categoryRepo.Create(data)
 }
 
@@ -31,15 +86,77 @@ func (s *CategoryService) Update(category *models.Category) error {
 	return s.categoryRepo.Update(category)
 }
 
-func (s *CategoryService) Delete(id int) error {
+// Move puts the category and its subtree under another parent.
+func (s *CategoryService) Move(id int, parentID *int) (*models.Category, error) {
+	if err := s.categoryRepo.Move(id, parentID); err != nil {
+		return nil, err
+	}
+
+	return s.categoryRepo.FindById(id)
+}
+
+// Delete removes the category. With a non-zero moveTo its products are
+// first moved to that category, in the same transaction; otherwise a
+// category that still has products is kept. It returns the number of
+// products moved.
+func (s *CategoryService) Delete(id int, moveTo int) (int, error) {
+	if moveTo != 0 {
+		return s.categoryRepo.DeleteMovingProducts(id, moveTo)
+	}
+
 	products, err := s.productRepo.FindByCategoryId(id)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if len(products) > 0 {
-		return fmt.Errorf("cannot delete category: category is still used by products")
+		return 0, fmt.Errorf("cannot delete category: category is still used by products")
+	}
+
+	hasChildren, err := s.categoryRepo.HasChildren(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if hasChildren {
+		return 0, fmt.Errorf("cannot delete category: category still has subcategories")
+	}
+
+	return 0, s.categoryRepo.Delete(id)
+}
+
+// categoryPaths maps every category to its path from the top level.
+func categoryPaths(categories []models.Category) map[int][]models.CategoryCrumb {
+	byID := make(map[int]models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+
+	paths := make(map[int][]models.CategoryCrumb, len(categories))
+	var pathOf func(id int, depth int) []models.CategoryCrumb
+	pathOf = func(id int, depth int) []models.CategoryCrumb {
+		if path, ok := paths[id]; ok {
+			return path
+		}
+
+		category, ok := byID[id]
+		if !ok || depth > len(categories) {
+			return nil
+		}
+
+		var path []models.CategoryCrumb
+		if category.ParentID != nil {
+			path = append(path, pathOf(*category.ParentID, depth+1)...)
+		}
+		path = append(path, models.CategoryCrumb{ID: category.ID, Name: category.Name})
+		paths[id] = path
+
+		return path
+	}
+
+	for _, category := range categories {
+		pathOf(category.ID, 0)
 	}
 
-	return s.categoryRepo.Delete(id)
+	return paths
 }
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type CustomerGroupService struct {
+	repo          *repositories.CustomerGroupRepository
+	priceListRepo *repositories.PriceListRepository
+}
+
+func NewCustomerGroupService(repo *repositories.CustomerGroupRepository, priceListRepo *repositories.PriceListRepository) *CustomerGroupService {
+	return &CustomerGroupService{repo: repo, priceListRepo: priceListRepo}
+}
+
+func (s *CustomerGroupService) GetAll() ([]models.CustomerGroup, error) {
+	return s.repo.FindAll()
+}
+
+func (s *CustomerGroupService) Create(data *models.CustomerGroup) error {
+	if err := s.setPriceList(data); err != nil {
+		return err
+	}
+
+	return s.repo.Create(data)
+}
+
+func (s *CustomerGroupService) GetById(id int) (*models.CustomerGroup, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *CustomerGroupService) Update(group *models.CustomerGroup) error {
+	if err := s.setPriceList(group); err != nil {
+		return err
+	}
+
+	return s.repo.Update(group)
+}
+
+func (s *CustomerGroupService) Delete(id int) error {
+	used, err := s.repo.HasCustomers(id)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return fmt.Errorf("cannot delete customer group: group still has customers")
+	}
+
+	return s.repo.Delete(id)
+}
+
+func (s *CustomerGroupService) setPriceList(group *models.CustomerGroup) error {
+	if group.PriceListID == nil {
+		group.PriceListName = ""
+		return nil
+	}
+
+	list, err := s.priceListRepo.FindById(*group.PriceListID)
+	if err != nil {
+		return err
+	}
+
+	group.PriceListName = list.Name
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type CustomerService struct {
+	repo      *repositories.CustomerRepository
+	groupRepo *repositories.CustomerGroupRepository
+}
+
+func NewCustomerService(repo *repositories.CustomerRepository, groupRepo *repositories.CustomerGroupRepository) *CustomerService {
+	return &CustomerService{repo: repo, groupRepo: groupRepo}
+}
+
+func (s *CustomerService) GetAll(name string, groupId int) ([]models.Customer, error) {
+	return s.repo.FindAll(name, groupId)
+}
+
+func (s *CustomerService) Create(data *models.Customer) error {
+	if err := s.setGroup(data); err != nil {
+		return err
+	}
+
+	return s.repo.Create(data)
+}
+
+func (s *CustomerService) GetById(id int) (*models.Customer, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *CustomerService) Update(customer *models.Customer) error {
+	if err := s.setGroup(customer); err != nil {
+		return err
+	}
+
+	return s.repo.Update(customer)
+}
+
+func (s *CustomerService) Delete(id int) error {
+	used, err := s.repo.HasTransactions(id)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return fmt.Errorf("cannot delete customer: customer still has transactions")
+	}
+
+	return s.repo.Delete(id)
+}
+
+func (s *CustomerService) setGroup(customer *models.Customer) error {
+	if customer.GroupID == nil {
+		customer.GroupName = ""
+		return nil
+	}
+
+	group, err := s.groupRepo.FindById(*customer.GroupID)
+	if err != nil {
+		return err
+	}
+
+	customer.GroupName = group.Name
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type LotService struct {
+	repo        *repositories.LotRepository
+	productRepo *repositories.ProductRepository
+}
+
+func NewLotService(repo *repositories.LotRepository, productRepo *repositories.ProductRepository) *LotService {
+	return &LotService{repo: repo, productRepo: productRepo}
+}
+
+func (s *LotService) GetByProductId(productId int, storeId int) ([]models.ProductLot, error) {
+	_, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByProductId(productId, storeId)
+}
+
+func (s *LotService) Create(lot *models.ProductLot) error {
+	return s.repo.Create(lot)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type ModifierGroupService struct {
+	repo        *repositories.ModifierGroupRepository
+	productRepo *repositories.ProductRepository
+}
+
+func NewModifierGroupService(repo *repositories.ModifierGroupRepository, productRepo *repositories.ProductRepository) *ModifierGroupService {
+	return &ModifierGroupService{repo: repo, productRepo: productRepo}
+}
+
+func (s *ModifierGroupService) GetAll() ([]models.ModifierGroup, error) {
+	return s.repo.FindAll()
+}
+
+func (s *ModifierGroupService) GetById(id int) (*models.ModifierGroup, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *ModifierGroupService) Create(group *models.ModifierGroup) error {
+	return s.repo.Create(group)
+}
+
+func (s *ModifierGroupService) Update(group *models.ModifierGroup) error {
+	return s.repo.Update(group)
+}
+
+// Delete removes the group and detaches it from its products. Past sales
+// keep the names and prices of the modifiers they used.
+func (s *ModifierGroupService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *ModifierGroupService) GetByProductId(productId int) ([]models.ModifierGroup, error) {
+	_, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByProductId(productId)
+}
+
+// ReplaceForProduct sets the groups offered on the product, in display
+// order.
+func (s *ModifierGroupService) ReplaceForProduct(productId int, groupIds []int) ([]models.ModifierGroup, error) {
+	_, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	for _, id := range groupIds {
+		if seen[id] {
+			return nil, fmt.Errorf("modifier group id %d appears more than once", id)
+		}
+		seen[id] = true
+	}
+
+	if err := s.repo.ReplaceForProduct(productId, groupIds); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByProductId(productId)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+	"log"
+	"math"
+	"slices"
+	"time"
+)
+
+type PriceChangeService struct {
+	repo        *repositories.PriceChangeRepository
+	productRepo *repositories.ProductRepository
+}
+
+func NewPriceChangeService(repo *repositories.PriceChangeRepository, productRepo *repositories.ProductRepository) *PriceChangeService {
+	return &PriceChangeService{repo: repo, productRepo: productRepo}
+}
+
+// GetProductPrices returns the product's current price, the scheduled
+// changes in the order they take effect and the history newest first.
+func (s *PriceChangeService) GetProductPrices(productId int) (*models.ProductPrices, error) {
+	product, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming, err := s.repo.FindByProductId(productId, models.PriceChangeStatusScheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.FindByProductId(productId, models.PriceChangeStatusApplied)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(history)
+
+	return &models.ProductPrices{
+		ProductID:    productId,
+		CurrentPrice: product.Price,
+		Upcoming:     upcoming,
+		History:      history,
+	}, nil
+}
+
+func (s *PriceChangeService) Schedule(change *models.PriceChange) error {
+	_, err := s.productRepo.FindById(change.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if !change.EffectiveAt.After(time.Now()) {
+		return fmt.Errorf("effective_at must be in the future")
+	}
+
+	return s.repo.Schedule(change)
+}
+
+func (s *PriceChangeService) Cancel(productId, id int) error {
+	return s.repo.Cancel(productId, id)
+}
+
+// BulkUpdate prices the selected products by the request's percentage or
+// amount. With apply false it is a preview and nothing is saved.
+func (s *PriceChangeService) BulkUpdate(req models.BulkPriceRequest, changedBy string, apply bool) (*models.BulkPriceResult, error) {
+	newPrice := func(price int) (int, error) {
+		next := float64(price)
+		if req.Percent != nil {
+			next = next * (100 + *req.Percent) / 100
+		}
+		if req.Amount != nil {
+			next += float64(*req.Amount)
+		}
+
+		if req.RoundTo > 0 {
+			next = math.Round(next/float64(req.RoundTo)) * float64(req.RoundTo)
+		}
+
+		rounded := int(math.Round(next))
+		if rounded <= 0 {
+			return 0, fmt.Errorf("new price %d must be greater than 0", rounded)
+		}
+
+		return rounded, nil
+	}
+
+	changes, err := s.repo.BulkUpdate(req, newPrice, changedBy, apply)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BulkPriceResult{
+		Applied: apply,
+		Count:   len(changes),
+		Changes: changes,
+	}
+	for _, change := range changes {
+		if change.NewPrice != change.OldPrice {
+			result.Changed++
+		}
+	}
+
+	return result, nil
+}
+
+// RunScheduler applies due price changes every interval. It never returns
+// and is meant to run in its own goroutine.
+func (s *PriceChangeService) RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.applyDue()
+		<-ticker.C
+	}
+}
+
+func (s *PriceChangeService) applyDue() {
+	applied, err := s.repo.ApplyDue(time.Now())
+	if err != nil {
+		log.Printf("applying scheduled price changes failed: %v", err)
+		return
+	}
+
+	if applied > 0 {
+		log.Printf("applied %d scheduled price changes", applied)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type PriceListService struct {
+	repo         *repositories.PriceListRepository
+	productRepo  *repositories.ProductRepository
+	categoryRepo *repositories.CategoryRepository
+}
+
+func NewPriceListService(repo *repositories.PriceListRepository, productRepo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository) *PriceListService {
+	return &PriceListService{repo: repo, productRepo: productRepo, categoryRepo: categoryRepo}
+}
+
+func (s *PriceListService) GetAll() ([]models.PriceList, error) {
+	return s.repo.FindAll()
+}
+
+func (s *PriceListService) Create(data *models.PriceList) error {
+	if err := s.checkItems(data.Items); err != nil {
+		return err
+	}
+
+	return s.repo.Create(data)
+}
+
+func (s *PriceListService) GetById(id int) (*models.PriceList, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *PriceListService) Update(list *models.PriceList) error {
+	if err := s.checkItems(list.Items); err != nil {
+		return err
+	}
+
+	return s.repo.Update(list)
+}
+
+func (s *PriceListService) Delete(id int) error {
+	used, err := s.repo.IsUsed(id)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return fmt.Errorf("cannot delete price list: price list is assigned to a customer group or was used in transactions")
+	}
+
+	return s.repo.Delete(id)
+}
+
+// checkItems makes sure every rule targets an existing product or category,
+// once.
+func (s *PriceListService) checkItems(items []models.PriceListItem) error {
+	products := make(map[int]bool)
+	categories := make(map[int]bool)
+
+	for _, item := range items {
+		if item.ProductID != nil {
+			if products[*item.ProductID] {
+				return fmt.Errorf("product id %d appears more than once", *item.ProductID)
+			}
+			products[*item.ProductID] = true
+
+			if _, err := s.productRepo.FindById(*item.ProductID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if categories[*item.CategoryID] {
+			return fmt.Errorf("category id %d appears more than once", *item.CategoryID)
+		}
+		categories[*item.CategoryID] = true
+
+		if _, err := s.categoryRepo.FindById(*item.CategoryID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
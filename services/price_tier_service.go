@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+	"slices"
+)
+
+type PriceTierService struct {
+	repo        *repositories.PriceTierRepository
+	productRepo *repositories.ProductRepository
+}
+
+func NewPriceTierService(repo *repositories.PriceTierRepository, productRepo *repositories.ProductRepository) *PriceTierService {
+	return &PriceTierService{repo: repo, productRepo: productRepo}
+}
+
+func (s *PriceTierService) GetByProductId(productId int) ([]models.PriceTier, error) {
+	_, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByProductId(productId)
+}
+
+// Replace sets the product's tiers. Each tier must start at a distinct
+// quantity above one and be cheaper than the product price and the tiers
+// below it.
+func (s *PriceTierService) Replace(productId int, tiers []models.PriceTier) ([]models.PriceTier, error) {
+	product, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(tiers, func(a, b models.PriceTier) int { return a.MinQuantity - b.MinQuantity })
+
+	previous := product.Price
+	for i, tier := range tiers {
+		if i > 0 && tier.MinQuantity == tiers[i-1].MinQuantity {
+			return nil, fmt.Errorf("duplicate tier for min quantity %d", tier.MinQuantity)
+		}
+
+		if tier.Price >= previous {
+			return nil, fmt.Errorf("tier price for min quantity %d must be lower than %d", tier.MinQuantity, previous)
+		}
+		previous = tier.Price
+	}
+
+	if err := s.repo.Replace(productId, tiers); err != nil {
+		return nil, err
+	}
+
+	return tiers, nil
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kasir-go/models"
+	"kasir-go/repositories"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportHeader names the columns of CSV and XLSX exports. They match the
+// headers the import recognises, so an export can be edited and imported
+// back.
+var exportHeader = []string{"id", "sku", "name", "category", "barcode", "price", "cost", "stock", "reorder_point", "reorder_quantity"}
+
+type ProductExportService struct {
+	productRepo *repositories.ProductRepository
+}
+
+func NewProductExportService(productRepo *repositories.ProductRepository) *ProductExportService {
+	return &ProductExportService{productRepo: productRepo}
+}
+
+// Export writes the products matching the list filter to w in the given
+// format. Rows are written as they are read from the database.
+func (s *ProductExportService) Export(w io.Writer, format string, filter models.ProductFilter) error {
+	switch format {
+	case FileFormatCSV:
+		return s.exportCSV(w, filter)
+	case FileFormatXLSX:
+		return s.exportXLSX(w, filter)
+	case FileFormatJSON:
+		return s.exportJSON(w, filter)
+	default:
+		return fmt.Errorf("unsupported export format %q, use csv, xlsx or json", format)
+	}
+}
+
+func (s *ProductExportService) exportCSV(w io.Writer, filter models.ProductFilter) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+
+	err := s.productRepo.Each(filter, func(p *models.Product) error {
+		return writer.Write([]string{
+			strconv.Itoa(p.ID), p.SKU, p.Name, p.CategoryName, p.Barcode, strconv.Itoa(p.Price), strconv.Itoa(p.Cost),
+			strconv.Itoa(p.Stock), strconv.Itoa(p.ReorderPoint), strconv.Itoa(p.ReorderQuantity),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// exportXLSX builds the sheet with a stream writer, which keeps rows on a
+// temporary file rather than in memory, and sends the workbook at the end.
+func (s *ProductExportService) exportXLSX(w io.Writer, filter models.ProductFilter) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(exportHeader))
+	for i, column := range exportHeader {
+		header[i] = column
+	}
+
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	row := 1
+	err = s.productRepo.Each(filter, func(p *models.Product) error {
+		row++
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+
+		return sw.SetRow(cell, []interface{}{
+			p.ID, p.SKU, p.Name, p.CategoryName, p.Barcode, p.Price, p.Cost, p.Stock, p.ReorderPoint, p.ReorderQuantity,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+
+	return f.Write(w)
+}
+
+func (s *ProductExportService) exportJSON(w io.Writer, filter models.ProductFilter) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	err := s.productRepo.Each(filter, func(p *models.Product) error {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]\n")
+
+	return err
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"kasir-go/models"
+	"kasir-go/repositories"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// File formats of product imports and exports. JSON is export only.
+const (
+	FileFormatCSV  = "csv"
+	FileFormatXLSX = "xlsx"
+	FileFormatJSON = "json"
+)
+
+// importColumns lists the product fields an import file can fill, with the
+// header names recognised for each when no mapping is given.
+var importColumns = map[string][]string{
+	"sku":              {"sku", "kode", "kode_barang", "product_code"},
+	"name":             {"name", "nama", "nama_produk", "product_name"},
+	"category":         {"category", "kategori", "category_name"},
+	"barcode":          {"barcode"},
+	"price":            {"price", "harga", "harga_jual"},
+	"cost":             {"cost", "harga_beli", "hpp"},
+	"stock":            {"stock", "stok", "qty"},
+	"reorder_point":    {"reorder_point"},
+	"reorder_quantity": {"reorder_quantity"},
+}
+
+type ProductImportService struct {
+	repo *repositories.ProductImportRepository
+}
+
+func NewProductImportService(repo *repositories.ProductImportRepository) *ProductImportService {
+	return &ProductImportService{repo: repo}
+}
+
+// Import reads products from a CSV or XLSX file and upserts them by SKU at
+// the outlet. mapping maps product fields to the file's header names and
+// overrides the recognised defaults. On a dry run, or when any row is
+// invalid, nothing is saved and the report tells what would happen.
+func (s *ProductImportService) Import(r io.Reader, format string, mapping map[string]string, storeId int, createdBy string, dryRun bool) (*models.ProductImportResult, error) {
+	rows, err := readImportRows(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("file has no product rows")
+	}
+
+	columns, err := mapImportColumns(rows[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]models.ProductImportRecord, 0, len(rows)-1)
+	invalid := make([]models.ProductImportRow, 0)
+	seen := make(map[string]int)
+
+	for i, cells := range rows[1:] {
+		if isBlankRow(cells) {
+			continue
+		}
+
+		record, errs := parseImportRecord(i+2, cells, columns)
+		if record.SKU != "" {
+			if first, ok := seen[record.SKU]; ok {
+				errs = append(errs, fmt.Sprintf("sku %s already appears on row %d", record.SKU, first))
+			} else {
+				seen[record.SKU] = record.Row
+			}
+		}
+
+		if len(errs) > 0 {
+			invalid = append(invalid, models.ProductImportRow{
+				Row:    record.Row,
+				SKU:    record.SKU,
+				Name:   record.Name,
+				Action: models.ProductImportError,
+				Errors: errs,
+			})
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	// the valid rows still run against the database so the report covers
+	// conflicts there too, but are only saved when the whole file is clean
+	result, err := s.repo.Import(records, storeId, createdBy, !dryRun && len(invalid) == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result.DryRun = dryRun
+	result.Rows = append(result.Rows, invalid...)
+	slices.SortFunc(result.Rows, func(a, b models.ProductImportRow) int {
+		return a.Row - b.Row
+	})
+
+	for _, row := range result.Rows {
+		switch row.Action {
+		case models.ProductImportCreate:
+			result.Created++
+		case models.ProductImportUpdate:
+			result.Updated++
+		default:
+			result.Failed++
+		}
+	}
+	result.Total = len(result.Rows)
+
+	return result, nil
+}
+
+// readImportRows returns the cells of the file, header first. For XLSX
+// only the first sheet is read.
+func readImportRows(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FileFormatCSV:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		// spreadsheets set to an Indonesian locale export with semicolons
+		reader := csv.NewReader(bytes.NewReader(data))
+		firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+			reader.Comma = ';'
+		}
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+
+		return rows, nil
+	case FileFormatXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xlsx: %w", err)
+		}
+		defer f.Close()
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("xlsx file has no sheets")
+		}
+
+		return f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	default:
+		return nil, fmt.Errorf("unsupported import format %q, use csv or xlsx", format)
+	}
+}
+
+// mapImportColumns returns the index of each product field in the header.
+func mapImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	index := make(map[string]int)
+	for i, name := range header {
+		key := normalizeHeader(name)
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, aliases := range importColumns {
+		if name, ok := mapping[field]; ok {
+			i, found := index[normalizeHeader(name)]
+			if !found {
+				return nil, fmt.Errorf("column %q mapped to %s is not in the file", name, field)
+			}
+			columns[field] = i
+			continue
+		}
+
+		for _, alias := range aliases {
+			if i, found := index[alias]; found {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	for field := range mapping {
+		if _, ok := importColumns[field]; !ok {
+			return nil, fmt.Errorf("unknown import field %q", field)
+		}
+	}
+
+	for _, field := range []string{"sku", "name"} {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("file needs a %s column", field)
+		}
+	}
+
+	return columns, nil
+}
+
+func normalizeHeader(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseImportRecord reads one data row and returns the problems found in
+// it. Empty optional cells stay nil.
+func parseImportRecord(row int, cells []string, columns map[string]int) (models.ProductImportRecord, []string) {
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(cells) {
+			return ""
+		}
+		return strings.TrimSpace(cells[i])
+	}
+
+	record := models.ProductImportRecord{
+		Row:      row,
+		SKU:      cell("sku"),
+		Name:     cell("name"),
+		Category: cell("category"),
+	}
+
+	var errs []string
+	if record.SKU == "" {
+		errs = append(errs, "sku is required")
+	} else if len(record.SKU) > 64 {
+		errs = append(errs, "sku must be at most 64 characters")
+	}
+
+	if record.Name == "" {
+		errs = append(errs, "name is required")
+	}
+
+	if barcode := cell("barcode"); barcode != "" {
+		record.Barcode = &barcode
+	}
+
+	number := func(field string, minimum int) *int {
+		value := cell(field)
+		if value == "" {
+			return nil
+		}
+
+		n, err := parseImportInt(value)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s %q is not a whole number", field, value))
+			return nil
+		}
+
+		if n < minimum {
+			errs = append(errs, fmt.Sprintf("%s must be at least %d", field, minimum))
+			return nil
+		}
+
+		return &n
+	}
+
+	record.Price = number("price", 1)
+	record.Cost = number("cost", 0)
+	record.Stock = number("stock", 0)
+	record.ReorderPoint = number("reorder_point", 0)
+	record.ReorderQuantity = number("reorder_quantity", 0)
+
+	return record, errs
+}
+
+// parseImportInt accepts whole numbers, also when a spreadsheet stored
+// them as decimals such as "15000.0".
+func parseImportInt(value string) (int, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		return n, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f != math.Trunc(f) {
+		return 0, fmt.Errorf("not a whole number")
+	}
+
+	return int(f), nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"fmt"
 	"kasir-go/models"
 	"kasir-go/repositories"
 )
@@ -8,23 +9,65 @@ import (
 type ProductService struct {
 	productRepo  *repositories.ProductRepository
 	categoryRepo *repositories.CategoryRepository
+	bundleRepo   *repositories.BundleRepository
+	modifierRepo *repositories.ModifierGroupRepository
 }
 
-func NewProductService(productRepo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository) *ProductService {
-	return &ProductService{productRepo: productRepo, categoryRepo: categoryRepo}
+func NewProductService(productRepo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository, bundleRepo *repositories.BundleRepository,
+	modifierRepo *repositories.ModifierGroupRepository) *ProductService {
+	return &ProductService{productRepo: productRepo, categoryRepo: categoryRepo, bundleRepo: bundleRepo, modifierRepo: modifierRepo}
 }
 
-func (s *ProductService) GetAll(name string) ([]models.Product, error) {
-	return s.productRepo.FindAll(name)
+// GetAll returns the page of products matching the filter and the number
+// of matches over all pages.
+func (s *ProductService) GetAll(filter models.ProductFilter) ([]models.Product, int, error) {
+	products, err := s.productRepo.FindAll(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(products) > 0 {
+		categories, err := s.categoryRepo.FindAll()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		paths := categoryPaths(categories)
+		for i := range products {
+			products[i].CategoryPath = paths[products[i].CategoryID]
+		}
+	}
+
+	total := len(products)
+	if filter.Limit > 0 || filter.Offset > 0 {
+		total, err = s.productRepo.Count(filter)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return products, total, nil
+}
+
+func (s *ProductService) GetLowStock(storeId int) ([]models.Product, error) {
+	return s.productRepo.FindLowStock(storeId)
 }
 
-func (s *ProductService) Create(data *models.Product) error {
+func (s *ProductService) Create(data *models.Product, createdBy string) error {
 	_, err := s.categoryRepo.FindById(data.CategoryID)
 	if err != nil {
 		return err
 	}
 
-	return s.productRepo.Create(data)
+	seen := make(map[int]bool)
+	for _, component := range data.Components {
+		if seen[component.ProductID] {
+			return fmt.Errorf("duplicate component product id %d", component.ProductID)
+		}
+		seen[component.ProductID] = true
+	}
+
+	return s.productRepo.Create(data, createdBy)
 }
 
 func (s *ProductService) GetById(id int) (*models.Product, error) {
@@ -38,26 +81,50 @@ func (s *ProductService) GetById(id int) (*models.Product, error) {
 		return nil, err
 	}
 
-	result := &models.Product{
-		ID:           product.ID,
-		Name:         product.Name,
-		Price:        product.Price,
-		CategoryID:   category.ID,
-		CategoryName: category.Name,
+	product.CategoryName = category.Name
+
+	product.CategoryPath, err = s.categoryRepo.FindPath(product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.IsBundle {
+		product.Components, err = s.bundleRepo.FindByProductId(product.ID, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	product.ModifierGroups, err = s.modifierRepo.FindByProductId(product.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return product, nil
 }
 
-func (s *ProductService) Update(product *models.Product) error {
+func (s *ProductService) Update(product *models.Product, updatedBy string) error {
 	_, err := s.categoryRepo.FindById(product.CategoryID)
 	if err != nil {
 		return err
 	}
 
-	return s.productRepo.Update(product)
+	return s.productRepo.Update(product, updatedBy)
 }
 
+func (s *ProductService) GetArchived() ([]models.Product, error) {
+	return s.productRepo.FindArchived()
+}
+
+// Delete archives the product rather than removing it.
 func (s *ProductService) Delete(id int) error {
-	return s.productRepo.Delete(id)
+	return s.productRepo.Archive(id)
+}
+
+func (s *ProductService) Restore(id int) (*models.Product, error) {
+	if err := s.productRepo.Restore(id); err != nil {
+		return nil, err
+	}
+
+	return s.GetById(id)
 }
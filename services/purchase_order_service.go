@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type PurchaseOrderService struct {
+	repo         *repositories.PurchaseOrderRepository
+	supplierRepo *repositories.SupplierRepository
+	productRepo  *repositories.ProductRepository
+}
+
+func NewPurchaseOrderService(repo *repositories.PurchaseOrderRepository, supplierRepo *repositories.SupplierRepository, productRepo *repositories.ProductRepository) *PurchaseOrderService {
+	return &PurchaseOrderService{repo: repo, supplierRepo: supplierRepo, productRepo: productRepo}
+}
+
+func (s *PurchaseOrderService) GetAll(status string, supplierId int) ([]models.PurchaseOrder, error) {
+	return s.repo.FindAll(status, supplierId)
+}
+
+func (s *PurchaseOrderService) GetById(id int) (*models.PurchaseOrder, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *PurchaseOrderService) Create(po *models.PurchaseOrder) error {
+	if err := s.validate(po); err != nil {
+		return err
+	}
+
+	return s.repo.Create(po)
+}
+
+func (s *PurchaseOrderService) Update(po *models.PurchaseOrder) error {
+	if err := s.validate(po); err != nil {
+		return err
+	}
+
+	return s.repo.Update(po)
+}
+
+func (s *PurchaseOrderService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *PurchaseOrderService) Send(id int) (*models.PurchaseOrder, error) {
+	if err := s.repo.Send(id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
+
+func (s *PurchaseOrderService) Receive(id int, items []models.ReceiveItem, receivedBy string) (*models.PurchaseOrder, error) {
+	if err := s.repo.Receive(id, items, receivedBy); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
+
+func (s *PurchaseOrderService) Close(id int) (*models.PurchaseOrder, error) {
+	if err := s.repo.Close(id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
+
+func (s *PurchaseOrderService) validate(po *models.PurchaseOrder) error {
+	_, err := s.supplierRepo.FindById(po.SupplierID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range po.Items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("quantity must be greater than 0 for product id %d", item.ProductID)
+		}
+
+		if item.UnitCost < 0 {
+			return fmt.Errorf("unit cost must not be negative for product id %d", item.ProductID)
+		}
+
+		_, err := s.productRepo.FindById(item.ProductID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,32 +1,43 @@
 package services
 
 import (
+	"fmt"
 	"kasir-go/models"
 	"kasir-go/repositories"
+	"math"
+	"slices"
 	"time"
 )
 
 type ReportService struct {
-	repo *repositories.TransactionRepository
+	repo              *repositories.TransactionRepository
+	purchaseOrderRepo *repositories.PurchaseOrderRepository
+	lotRepo           *repositories.LotRepository
+	storeRepo         *repositories.StoreRepository
+	// expiryHorizons are the default day buckets of the expiring report.
+	expiryHorizons []int
 }
 
-func NewReportService(repo *repositories.TransactionRepository) *ReportService {
-	return &ReportService{repo: repo}
+func NewReportService(repo *repositories.TransactionRepository, purchaseOrderRepo *repositories.PurchaseOrderRepository, lotRepo *repositories.LotRepository, storeRepo *repositories.StoreRepository, expiryHorizons []int) *ReportService {
+	return &ReportService{repo: repo, purchaseOrderRepo: purchaseOrderRepo, lotRepo: lotRepo, storeRepo: storeRepo, expiryHorizons: expiryHorizons}
 }
 
-func (s *ReportService) GetTodayReport() (*models.TodayReport, error) {
-	loc, _ := time.LoadLocation("Asia/Jakarta")
+func (s *ReportService) GetTodayReport(storeId int) (*models.TodayReport, error) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return nil, fmt.Errorf("load report timezone: %w", err)
+	}
 	now := time.Now().In(loc)
 
 	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	end := start.Add(24 * time.Hour)
 
-	totalRevenue, totalTransaction, err := s.repo.GetSummaryByPeriod(start, end)
+	totalRevenue, totalTransaction, err := s.repo.GetSummaryByPeriod(start, end, storeId)
 	if err != nil {
 		return nil, err
 	}
 
-	productName, productQuantitySold, err := s.repo.GetBestSellingProductByPeriod(start, end)
+	productName, productQuantitySold, err := s.repo.GetBestSellingProductByPeriod(start, end, storeId)
 	if err != nil {
 		return nil, err
 	}
@@ -46,31 +57,18 @@ func (s *ReportService) GetTodayReport() (*models.TodayReport, error) {
 	}, nil
 }
 
-func (s *ReportService) GetReport(startDate, endDate *time.Time) (*models.TodayReport, error) {
-	loc, _ := time.LoadLocation("Asia/Jakarta")
-
-	var start time.Time
-	if startDate != nil {
-		sd := startDate.In(loc)
-		start = time.Date(sd.Year(), sd.Month(), sd.Day(), 0, 0, 0, 0, loc)
-	} else {
-		start = time.Date(2026, time.January, 1, 0, 0, 0, 0, loc)
-	}
-
-	var end time.Time
-	if endDate != nil {
-		ed := endDate.In(loc)
-		end = time.Date(ed.Year(), ed.Month(), ed.Day(), 23, 59, 59, 0, loc)
-	} else {
-		end = time.Now().In(loc)
+func (s *ReportService) GetReport(startDate, endDate *time.Time, storeId int) (*models.TodayReport, error) {
+	start, end, err := reportPeriod(startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
 
-	totalRevenue, totalTransaction, err := s.repo.GetSummaryByPeriod(start, end)
+	totalRevenue, totalTransaction, err := s.repo.GetSummaryByPeriod(start, end, storeId)
 	if err != nil {
 		return nil, err
 	}
 
-	productName, productQuantitySold, err := s.repo.GetBestSellingProductByPeriod(start, end)
+	productName, productQuantitySold, err := s.repo.GetBestSellingProductByPeriod(start, end, storeId)
 	if err != nil {
 		return nil, err
 	}
@@ -89,3 +87,307 @@ func (s *ReportService) GetReport(startDate, endDate *time.Time) (*models.TodayR
 		BestSellingProduct: bestProduct,
 	}, nil
 }
+
+func (s *ReportService) GetOpenPurchaseOrders(storeId int) ([]models.OpenPurchaseOrder, error) {
+	return s.purchaseOrderRepo.FindOpen(storeId)
+}
+
+func (s *ReportService) GetSupplierSpend(startDate, endDate *time.Time, storeId int) ([]models.SupplierSpend, error) {
+	start, end, err := reportPeriod(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.purchaseOrderRepo.GetSupplierSpendByPeriod(start, end, storeId)
+}
+
+// GetComponentUsage returns how much of each bundle component left stock
+// in the period, through bundles and on its own.
+func (s *ReportService) GetComponentUsage(startDate, endDate *time.Time, storeId int) ([]models.ComponentUsage, error) {
+	start, end, err := reportPeriod(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetComponentUsageByPeriod(start, end, storeId)
+}
+
+// GetIngredientUsage returns what the recipes of the menu items sold in
+// the period used of each ingredient.
+func (s *ReportService) GetIngredientUsage(startDate, endDate *time.Time, storeId int) ([]models.IngredientUsage, error) {
+	start, end, err := reportPeriod(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetIngredientUsageByPeriod(start, end, storeId)
+}
+
+// GetProfitReport returns revenue, COGS and gross profit for the period,
+// optionally broken down by groupBy (product, category, day, week or
+// month) and limited to a category with its subcategories. The period and
+// the day, week and month buckets follow the outlet's timezone, or
+// Asia/Jakarta across all outlets.
+func (s *ReportService) GetProfitReport(startDate, endDate *time.Time, groupBy string, storeId, categoryId int) (*models.ProfitReport, error) {
+	loc, err := s.location(storeId)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := reportPeriodIn(startDate, endDate, loc)
+
+	report := &models.ProfitReport{GroupBy: groupBy}
+
+	totals, err := s.repo.GetProfitByPeriod(start, end, "", loc, storeId, categoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range totals {
+		report.TotalRevenue += row.Revenue
+		report.TotalCOGS += row.COGS
+	}
+	report.GrossProfit = report.TotalRevenue - report.TotalCOGS
+	report.Margin = marginPercent(report.GrossProfit, report.TotalRevenue)
+
+	if groupBy == "" {
+		return report, nil
+	}
+
+	rows, err := s.repo.GetProfitByPeriod(start, end, groupBy, loc, storeId, categoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		rows[i].GrossProfit = rows[i].Revenue - rows[i].COGS
+		rows[i].Margin = marginPercent(rows[i].GrossProfit, rows[i].Revenue)
+	}
+	report.Rows = rows
+
+	return report, nil
+}
+
+// GetSalesSeries returns the sales of the period per hour, day, week or
+// month, with empty buckets filled with zeros. Buckets follow the outlet's
+// timezone, or Asia/Jakarta across all outlets.
+func (s *ReportService) GetSalesSeries(startDate, endDate *time.Time, interval string, storeId int) (*models.SalesSeries, error) {
+	loc, err := s.location(storeId)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := reportPeriodIn(startDate, endDate, loc)
+
+	rows, err := s.repo.GetSalesSeriesByPeriod(start, end, interval, loc, storeId)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make(map[int64]models.SalesBucket, len(rows))
+	for _, row := range rows {
+		sales[row.Start.Unix()] = row
+	}
+
+	series := &models.SalesSeries{
+		Interval: interval,
+		Timezone: loc.String(),
+		Buckets:  make([]models.SalesBucket, 0),
+	}
+
+	for bucketStart := seriesBucketStart(start, interval); bucketStart.Before(end); bucketStart = nextSeriesBucket(bucketStart, interval) {
+		bucket, ok := sales[bucketStart.Unix()]
+		if !ok {
+			bucket = models.SalesBucket{Start: bucketStart}
+		}
+
+		if bucket.TransactionCount > 0 {
+			bucket.AverageBasket = bucket.Revenue / bucket.TransactionCount
+		}
+
+		series.Buckets = append(series.Buckets, bucket)
+	}
+
+	return series, nil
+}
+
+// seriesBucketStart truncates t to the start of its bucket, in t's
+// location. Weeks start on Monday, as in Postgres date_trunc.
+func seriesBucketStart(t time.Time, interval string) time.Time {
+	switch interval {
+	case models.SeriesIntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case models.SeriesIntervalWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	case models.SeriesIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextSeriesBucket returns the start of the bucket after the one starting
+// at t, stepping by wall clock so days and months stay aligned across
+// daylight saving changes.
+func nextSeriesBucket(t time.Time, interval string) time.Time {
+	switch interval {
+	case models.SeriesIntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case models.SeriesIntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case models.SeriesIntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// GetSalesRanking returns the top, or with bottom the bottom, limit
+// products or categories of the period by quantity, revenue or profit,
+// each with its share of the total. categoryId narrows products to a
+// subtree and ranks the categories one level below it.
+func (s *ReportService) GetSalesRanking(startDate, endDate *time.Time, groupBy, by string, bottom bool, limit, storeId, categoryId int) (*models.SalesRanking, error) {
+	start, end, err := reportPeriod(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, total, err := s.repo.GetSalesRankingByPeriod(start, end, groupBy, by, bottom, limit, storeId, categoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		value := rows[i].Quantity
+		switch by {
+		case models.RankingByRevenue:
+			value = rows[i].Revenue
+		case models.RankingByProfit:
+			value = rows[i].Profit
+		}
+		rows[i].Share = percentOf(value, total)
+	}
+
+	return &models.SalesRanking{
+		GroupBy:    groupBy,
+		By:         by,
+		Bottom:     bottom,
+		CategoryID: categoryId,
+		Total:      total,
+		Rows:       rows,
+	}, nil
+}
+
+// marginPercent returns profit as a percentage of revenue, rounded to two
+// decimals.
+func marginPercent(profit, revenue int) float64 {
+	return percentOf(profit, revenue)
+}
+
+// percentOf returns part as a percentage of whole, rounded to two
+// decimals, or zero when whole is zero.
+func percentOf(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
+
+// GetExpiringReport buckets lots with stock left by how soon they expire.
+// The first bucket (within_days 0) holds lots that have already expired;
+// each lot is placed in the smallest horizon it fits. Nil horizons use the
+// configured defaults.
+func (s *ReportService) GetExpiringReport(horizons []int, storeId int) ([]models.ExpiryBucket, error) {
+	if len(horizons) == 0 {
+		horizons = s.expiryHorizons
+	}
+
+	horizons = slices.Clone(horizons)
+	slices.Sort(horizons)
+	horizons = slices.Compact(horizons)
+
+	lots, err := s.lotRepo.FindExpiring(horizons[len(horizons)-1], storeId)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]models.ExpiryBucket, 0, len(horizons)+1)
+	buckets = append(buckets, models.ExpiryBucket{WithinDays: 0, Lots: make([]models.ProductLot, 0)})
+	for _, days := range horizons {
+		buckets = append(buckets, models.ExpiryBucket{WithinDays: days, Lots: make([]models.ProductLot, 0)})
+	}
+
+	for _, lot := range lots {
+		i := 0
+		if lot.DaysLeft >= 0 {
+			i = 1 + slices.IndexFunc(horizons, func(days int) bool { return lot.DaysLeft <= days })
+		}
+
+		buckets[i].Lots = append(buckets[i].Lots, lot)
+		buckets[i].Quantity += lot.Quantity
+		buckets[i].CostValue += lot.Quantity * lot.UnitCost
+	}
+
+	return buckets, nil
+}
+
+// location returns the timezone the reports of an outlet follow: the
+// outlet's own, or Asia/Jakarta across all outlets.
+func (s *ReportService) location(storeId int) (*time.Location, error) {
+	if storeId == 0 {
+		loc, err := time.LoadLocation("Asia/Jakarta")
+		if err != nil {
+			return nil, fmt.Errorf("load report timezone: %w", err)
+		}
+
+		return loc, nil
+	}
+
+	store, err := s.storeRepo.FindById(storeId)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(store.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("store id %d has invalid timezone %s", storeId, store.Timezone)
+	}
+
+	return loc, nil
+}
+
+// reportPeriod turns optional calendar dates into an Asia/Jakarta time
+// range. A missing start defaults to 1 January 2026 and a missing end to
+// now.
+func reportPeriod(startDate, endDate *time.Time) (start, end time.Time, err error) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return start, end, fmt.Errorf("load report timezone: %w", err)
+	}
+
+	start, end = reportPeriodIn(startDate, endDate, loc)
+
+	return start, end, nil
+}
+
+// reportPeriodIn is reportPeriod in loc. The calendar dates are taken as
+// given, so a date parsed in Asia/Jakarta names the same day in loc.
+func reportPeriodIn(startDate, endDate *time.Time, loc *time.Location) (start, end time.Time) {
+	if startDate != nil {
+		sd := *startDate
+		start = time.Date(sd.Year(), sd.Month(), sd.Day(), 0, 0, 0, 0, loc)
+	} else {
+		start = time.Date(2026, time.January, 1, 0, 0, 0, 0, loc)
+	}
+
+	if endDate != nil {
+		ed := *endDate
+		end = time.Date(ed.Year(), ed.Month(), ed.Day(), 23, 59, 59, 0, loc)
+	} else {
+		end = time.Now().In(loc)
+	}
+
+	return start, end
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type StockAdjustmentService struct {
+	repo        *repositories.StockAdjustmentRepository
+	productRepo *repositories.ProductRepository
+	// approvalThreshold is the adjustment value at cost in Rupiah above
+	// which an adjustment waits for approval. Zero applies every adjustment
+	// directly.
+	approvalThreshold int
+	managerKey        string
+}
+
+func NewStockAdjustmentService(repo *repositories.StockAdjustmentRepository, productRepo *repositories.ProductRepository, approvalThreshold int, managerKey string) *StockAdjustmentService {
+	return &StockAdjustmentService{repo: repo, productRepo: productRepo, approvalThreshold: approvalThreshold, managerKey: managerKey}
+}
+
+// IsManager reports whether managerKey authorizes approving and rejecting
+// adjustments.
+func (s *StockAdjustmentService) IsManager(managerKey string) bool {
+	return validManagerKey(managerKey, s.managerKey)
+}
+
+func (s *StockAdjustmentService) GetAll(status string) ([]models.StockAdjustment, error) {
+	return s.repo.FindAll(status)
+}
+
+func (s *StockAdjustmentService) GetById(id int) (*models.StockAdjustment, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *StockAdjustmentService) Create(req models.StockAdjustmentRequest, createdBy string) (*models.StockAdjustment, error) {
+	product, err := s.productRepo.FindByIdAtStore(req.ProductID, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+
+	var quantity int
+	if req.NewStock != nil {
+		quantity = *req.NewStock - product.Stock
+	} else {
+		quantity = *req.Quantity
+	}
+
+	// the threshold is on the cost of the stock written off or found; the
+	// stored value becomes the ledger cost once the adjustment is posted
+	value := quantity * product.Cost
+	if value < 0 {
+		value = -value
+	}
+
+	status := models.AdjustmentStatusApplied
+	if s.approvalThreshold > 0 && value > s.approvalThreshold {
+		status = models.AdjustmentStatusPending
+	}
+
+	adjustment := &models.StockAdjustment{
+		ProductID: req.ProductID,
+		StoreID:   req.StoreID,
+		Quantity:  quantity,
+		NewStock:  req.NewStock,
+		Reason:    req.Reason,
+		Notes:     req.Notes,
+		Value:     value,
+		Status:    status,
+		CreatedBy: createdBy,
+	}
+
+	err = s.repo.Create(adjustment)
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustment, nil
+}
+
+func (s *StockAdjustmentService) Approve(id int, reviewedBy string) (*models.StockAdjustment, error) {
+	return s.repo.Approve(id, reviewedBy)
+}
+
+func (s *StockAdjustmentService) Reject(id int, reviewedBy string) (*models.StockAdjustment, error) {
+	return s.repo.Reject(id, reviewedBy)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type StockCountService struct {
+	repo         *repositories.StockCountRepository
+	productRepo  *repositories.ProductRepository
+	categoryRepo *repositories.CategoryRepository
+}
+
+func NewStockCountService(repo *repositories.StockCountRepository, productRepo *repositories.ProductRepository, categoryRepo *repositories.CategoryRepository) *StockCountService {
+	return &StockCountService{repo: repo, productRepo: productRepo, categoryRepo: categoryRepo}
+}
+
+func (s *StockCountService) GetAll() ([]models.StockCount, error) {
+	return s.repo.FindAll()
+}
+
+func (s *StockCountService) GetById(id int) (*models.StockCount, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *StockCountService) Start(count *models.StockCount) error {
+	if count.CategoryID != nil {
+		_, err := s.categoryRepo.FindById(*count.CategoryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Create(count)
+}
+
+// AddEntry records a counted quantity. The product can be given by ID or
+// by the scanned barcode.
+func (s *StockCountService) AddEntry(entry *models.StockCountEntry) error {
+	if entry.ProductID == 0 {
+		product, err := s.productRepo.FindByBarcode(entry.Barcode)
+		if err != nil {
+			return err
+		}
+		entry.ProductID = product.ID
+	}
+
+	return s.repo.AddEntry(entry)
+}
+
+func (s *StockCountService) GetVarianceReport(id int) (*models.StockCountVarianceReport, error) {
+	count, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.FindVariance(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.StockCountVarianceReport{
+		CountID: count.ID,
+		Status:  count.Status,
+		Items:   items,
+	}
+
+	for _, item := range items {
+		if item.CountedStock == nil {
+			report.UncountedItems++
+			continue
+		}
+		report.TotalVariance += item.Variance
+		report.TotalValueImpact += item.ValueImpact
+	}
+
+	return report, nil
+}
+
+// GetIngredientVarianceReport compares recipe usage with counted usage of
+// the ingredients in the count.
+func (s *StockCountService) GetIngredientVarianceReport(id int) (*models.IngredientVarianceReport, error) {
+	count, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.FindIngredientVariance(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.IngredientVarianceReport{
+		CountID: count.ID,
+		Status:  count.Status,
+		Items:   items,
+	}
+
+	for _, item := range items {
+		report.TotalValueImpact += item.ValueImpact
+	}
+
+	return report, nil
+}
+
+func (s *StockCountService) Finalize(id int, finalizedBy string) (*models.StockCount, error) {
+	if err := s.repo.Finalize(id, finalizedBy); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
+
+func (s *StockCountService) Cancel(id int, cancelledBy string) (*models.StockCount, error) {
+	if err := s.repo.Cancel(id, cancelledBy); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
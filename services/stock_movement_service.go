@@ -0,0 +1,39 @@
+package services
+
+import (
+	"kasir-go/models"
+	"kasir-go/repositories"
+	"time"
+)
+
+type StockMovementService struct {
+	repo        *repositories.StockMovementRepository
+	productRepo *repositories.ProductRepository
+}
+
+func NewStockMovementService(repo *repositories.StockMovementRepository, productRepo *repositories.ProductRepository) *StockMovementService {
+	return &StockMovementService{repo: repo, productRepo: productRepo}
+}
+
+func (s *StockMovementService) GetByProductId(productId int, storeId int) ([]models.StockMovement, error) {
+	_, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindByProductId(productId, storeId)
+}
+
+func (s *StockMovementService) GetStockAsOf(productId int, storeId int, asOf time.Time) (*models.StockAsOf, error) {
+	stock, err := s.repo.GetStockAsOf(productId, storeId, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StockAsOf{
+		ProductID: productId,
+		StoreID:   storeId,
+		Stock:     stock,
+		AsOf:      asOf,
+	}, nil
+}
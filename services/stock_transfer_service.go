@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type StockTransferService struct {
+	repo        *repositories.StockTransferRepository
+	productRepo *repositories.ProductRepository
+	managerKey  string
+}
+
+func NewStockTransferService(repo *repositories.StockTransferRepository, productRepo *repositories.ProductRepository, managerKey string) *StockTransferService {
+	return &StockTransferService{repo: repo, productRepo: productRepo, managerKey: managerKey}
+}
+
+func (s *StockTransferService) GetAll(status string, storeId int) ([]models.StockTransfer, error) {
+	return s.repo.FindAll(status, storeId)
+}
+
+func (s *StockTransferService) GetById(id int) (*models.StockTransfer, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *StockTransferService) GetInTransit(storeId int) ([]models.InTransitItem, error) {
+	return s.repo.FindInTransit(storeId)
+}
+
+func (s *StockTransferService) Create(transfer *models.StockTransfer) error {
+	if err := s.validate(transfer); err != nil {
+		return err
+	}
+
+	return s.repo.Create(transfer)
+}
+
+func (s *StockTransferService) Update(transfer *models.StockTransfer) error {
+	if err := s.validate(transfer); err != nil {
+		return err
+	}
+
+	return s.repo.Update(transfer)
+}
+
+// Ship sends the transfer. managerKey authorizes shipping expired lots.
+func (s *StockTransferService) Ship(id int, req models.TransferShipRequest, shippedBy string, managerKey string) (*models.StockTransfer, error) {
+	if req.AllowExpired && !validManagerKey(managerKey, s.managerKey) {
+		return nil, fmt.Errorf("shipping expired lots requires a valid manager key")
+	}
+
+	if err := s.repo.Ship(id, req.AllowExpired, shippedBy); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
+
+func (s *StockTransferService) Receive(id int, items []models.TransferReceiveItem, receivedBy string) (*models.StockTransfer, error) {
+	if err := s.repo.Receive(id, items, receivedBy); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
+
+func (s *StockTransferService) Cancel(id int, _ string) (*models.StockTransfer, error) {
+	if err := s.repo.Cancel(id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindById(id)
+}
+
+func (s *StockTransferService) validate(transfer *models.StockTransfer) error {
+	if transfer.FromStoreID == transfer.ToStoreID {
+		return fmt.Errorf("source and destination store must differ")
+	}
+
+	for _, item := range transfer.Items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("quantity must be greater than 0 for product id %d", item.ProductID)
+		}
+
+		_, err := s.productRepo.FindById(item.ProductID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
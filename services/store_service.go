@@ -0,0 +1,56 @@
+package services
+
+import (
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type StoreService struct {
+	repo        *repositories.StoreRepository
+	productRepo *repositories.ProductRepository
+}
+
+func NewStoreService(repo *repositories.StoreRepository, productRepo *repositories.ProductRepository) *StoreService {
+	return &StoreService{repo: repo, productRepo: productRepo}
+}
+
+func (s *StoreService) GetAll() ([]models.Store, error) {
+	return s.repo.FindAll()
+}
+
+func (s *StoreService) Create(data *models.Store) error {
+	return s.repo.Create(data)
+}
+
+func (s *StoreService) GetById(id int) (*models.Store, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *StoreService) Update(store *models.Store) error {
+	return s.repo.Update(store)
+}
+
+func (s *StoreService) GetProductStores(productId int) ([]models.StoreProduct, error) {
+	_, err := s.productRepo.FindById(productId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.FindProductStores(productId)
+}
+
+func (s *StoreService) SetProductPrice(sp *models.StoreProduct) error {
+	_, err := s.productRepo.FindById(sp.ProductID)
+	if err != nil {
+		return err
+	}
+
+	store, err := s.repo.FindById(sp.StoreID)
+	if err != nil {
+		return err
+	}
+
+	sp.StoreName = store.Name
+
+	return s.repo.SetPrice(sp)
+}
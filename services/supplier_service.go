@@ -0,0 +1,44 @@
+package services
+
+import (
+	"fmt"
+	"kasir-go/models"
+	"kasir-go/repositories"
+)
+
+type SupplierService struct {
+	repo *repositories.SupplierRepository
+}
+
+func NewSupplierService(repo *repositories.SupplierRepository) *SupplierService {
+	return &SupplierService{repo: repo}
+}
+
+func (s *SupplierService) GetAll() ([]models.Supplier, error) {
+	return s.repo.FindAll()
+}
+
+func (s *SupplierService) Create(data *models.Supplier) error {
+	return s.repo.Create(data)
+}
+
+func (s *SupplierService) GetById(id int) (*models.Supplier, error) {
+	return s.repo.FindById(id)
+}
+
+func (s *SupplierService) Update(supplier *models.Supplier) error {
+	return s.repo.Update(supplier)
+}
+
+func (s *SupplierService) Delete(id int) error {
+	used, err := s.repo.HasPurchaseOrders(id)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		return fmt.Errorf("cannot delete supplier: supplier still has purchase orders")
+	}
+
+	return s.repo.Delete(id)
+}
@@ -1,18 +1,61 @@
 package services
 
 import (
+	"crypto/subtle"
+	"fmt"
 	"kasir-go/models"
+	"kasir-go/notifiers"
 	"kasir-go/repositories"
+	"log"
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo       *repositories.TransactionRepository
+	notifier   notifiers.Notifier
+	managerKey string
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func NewTransactionService(repo *repositories.TransactionRepository, notifier notifiers.Notifier, managerKey string) *TransactionService {
+	return &TransactionService{repo: repo, notifier: notifier, managerKey: managerKey}
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(items)
+// Checkout creates the transaction. managerKey authorizes overrides such
+// as selling expired lots.
+func (s *TransactionService) Checkout(req models.CheckoutRequest, cashier string, managerKey string) (*models.Transaction, error) {
+	if req.AllowExpired && !validManagerKey(managerKey, s.managerKey) {
+		return nil, fmt.Errorf("selling expired lots requires a valid manager key")
+	}
+
+	transaction, err := s.repo.CreateTransaction(req, cashier)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transaction.LowStock) > 0 {
+		go s.notifyLowStock(transaction)
+	}
+
+	return transaction, nil
+}
+
+// notifyLowStock alerts for every product this transaction pushed to or
+// below its reorder point at the transaction's outlet. The crossings are
+// found from the ledger balances inside the transaction, so each is
+// reported once however sales interleave.
+func (s *TransactionService) notifyLowStock(transaction *models.Transaction) {
+	for _, alert := range transaction.LowStock {
+		subject := fmt.Sprintf("Low stock: %s", alert.ProductName)
+		message := fmt.Sprintf("%s (id %d) is down to %d at store id %d after transaction #%d. Reorder point is %d, suggested reorder quantity is %d.",
+			alert.ProductName, alert.ProductID, alert.Stock, transaction.StoreID, transaction.ID, alert.ReorderPoint, alert.ReorderQuantity)
+
+		if err := s.notifier.Notify(subject, message); err != nil {
+			log.Printf("low stock notification failed for product id %d: %v", alert.ProductID, err)
+		}
+	}
+}
+
+// validManagerKey reports whether given matches the configured manager key.
+// Nothing matches when no key is configured.
+func validManagerKey(given, configured string) bool {
+	return configured != "" && subtle.ConstantTimeCompare([]byte(given), []byte(configured)) == 1
 }